@@ -0,0 +1,99 @@
+package scan
+
+import (
+	"strings"
+	"unicode/utf8"
+)
+
+// Buffer collects the runes for a token value or literal. It has the same
+// write methods as a strings.Builder.
+//
+// When the scanner is reading from a source held in memory, runes that are
+// added in the same order that they appear in the source are tracked as a
+// slice of that source and String returns a substring without making a copy.
+// Once the contents diverge from the source, such as when an escape sequence
+// is decoded or a digit separator is skipped, the buffer falls back to
+// copying into a strings.Builder.
+type Buffer struct {
+	src    string
+	shared bool
+	start  int
+	end    int
+	copied bool
+	b      strings.Builder
+}
+
+func (b *Buffer) init(src string, shared bool) {
+	b.src = src
+	b.shared = shared
+	b.Reset()
+}
+
+// add appends ch which was found at offset off in the source and is width
+// bytes in length.
+func (b *Buffer) add(ch rune, off int, width int) {
+	if b.shared && !b.copied && !(ch == utf8.RuneError && width == 1) {
+		switch {
+		case b.start == b.end:
+			b.start, b.end = off, off+width
+			return
+		case b.end == off:
+			b.end += width
+			return
+		}
+	}
+	b.spill()
+	b.b.WriteRune(ch)
+}
+
+// spill copies the shared slice of the source into the builder.
+func (b *Buffer) spill() {
+	if b.copied {
+		return
+	}
+	b.copied = true
+	if b.start < b.end {
+		b.b.WriteString(b.src[b.start:b.end])
+	}
+}
+
+func (b *Buffer) Len() int {
+	if !b.copied {
+		return b.end - b.start
+	}
+	return b.b.Len()
+}
+
+func (b *Buffer) Reset() {
+	b.start = 0
+	b.end = 0
+	b.copied = false
+	b.b.Reset()
+}
+
+func (b *Buffer) String() string {
+	if !b.copied {
+		return b.src[b.start:b.end]
+	}
+	return b.b.String()
+}
+
+func (b *Buffer) Write(p []byte) (int, error) {
+	b.spill()
+	return b.b.Write(p)
+}
+
+func (b *Buffer) WriteByte(c byte) error {
+	b.spill()
+	return b.b.WriteByte(c)
+}
+
+func (b *Buffer) WriteRune(ch rune) (int, error) {
+	b.spill()
+	return b.b.WriteRune(ch)
+}
+
+func (b *Buffer) WriteString(s string) (int, error) {
+	b.spill()
+	return b.b.WriteString(s)
+}
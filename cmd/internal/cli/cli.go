@@ -0,0 +1,110 @@
+// Package cli contains the code shared by the scanner commands.
+package cli
+
+import (
+	"encoding/json"
+	"flag"
+	"fmt"
+	"io"
+	"log"
+	"os"
+	"slices"
+
+	"github.com/blackchip-org/scan"
+)
+
+// Options are the command line flags common to each command.
+type Options struct {
+	JSON   bool
+	Check  bool
+	Format string
+}
+
+// Flags registers the options with fs.
+func (o *Options) Flags(fs *flag.FlagSet) {
+	fs.BoolVar(&o.JSON, "json", false, "output tokens as json")
+	fs.BoolVar(&o.Check, "check", false, "only output diagnostics")
+	fs.StringVar(&o.Format, "format", "text", "format of diagnostics with -check: text, json, or sarif")
+}
+
+// Run scans the file at path with rules and writes the output selected by
+// opts. The exit code of the command is returned, which is 1 when illegal
+// tokens are found and 2 when the file cannot be scanned.
+func Run(tool string, path string, rules scan.RuleSet, opts Options) int {
+	log.SetFlags(0)
+	if opts.Check && !slices.Contains(formats, opts.Format) {
+		log.Printf("unknown format: %v", scan.Quote(opts.Format))
+		return 2
+	}
+	src, err := os.ReadFile(path)
+	if err != nil {
+		log.Print(err)
+		return 2
+	}
+
+	s := scan.NewScannerFromBytes(path, src)
+	r := scan.NewRunner(s, rules)
+	toks := r.All()
+
+	errs := scan.Errors{}
+	status := 0
+	for _, t := range toks {
+		errs = append(errs, t.Errs...)
+		if t.Type == scan.IllegalType || t.Type == scan.ErrorType {
+			status = 1
+		}
+	}
+
+	if opts.Check {
+		if err := writeDiagnostics(os.Stdout, tool, string(src), errs, opts.Format); err != nil {
+			log.Print(err)
+			return 2
+		}
+		return status
+	}
+
+	if opts.JSON {
+		for i, t := range toks {
+			if t.Val == t.Lit {
+				toks[i].Lit = ""
+			}
+		}
+		data, err := json.MarshalIndent(toks, "", "  ")
+		if err != nil {
+			log.Print(err)
+			return 2
+		}
+		fmt.Println(string(data))
+	} else {
+		// Errors are rendered with the source below instead
+		for i := range toks {
+			toks[i].Errs = nil
+		}
+		fmt.Println(scan.FormatTokenTable(toks))
+	}
+
+	if err := scan.Render(os.Stderr, string(src), errs); err != nil {
+		log.Print(err)
+		return 2
+	}
+	return status
+}
+
+var formats = []string{"text", "json", "sarif"}
+
+func writeDiagnostics(w io.Writer, tool string, src string, errs scan.Errors, format string) error {
+	switch format {
+	case "text":
+		return scan.Render(w, src, errs)
+	case "json":
+		data, err := json.MarshalIndent(errs, "", "  ")
+		if err != nil {
+			return err
+		}
+		_, err = fmt.Fprintln(w, string(data))
+		return err
+	case "sarif":
+		return scan.WriteSARIF(w, tool, errs)
+	}
+	return fmt.Errorf("unknown format: %v", scan.Quote(format))
+}
@@ -0,0 +1,42 @@
+package main
+
+import (
+	"bytes"
+	"flag"
+	"fmt"
+	"log"
+	"os"
+
+	"github.com/blackchip-org/scan/gen"
+	"github.com/blackchip-org/scan/spec"
+)
+
+func main() {
+	var specFile, pkg, out string
+	flag.StringVar(&specFile, "spec", "", "file with the lexer spec")
+	flag.StringVar(&pkg, "pkg", "lexer", "package name of the generated source")
+	flag.StringVar(&out, "o", "", "output file (default stdout)")
+	flag.Parse()
+
+	if flag.NArg() != 0 || specFile == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	log.SetFlags(0)
+	sp, err := spec.Load(specFile)
+	if err != nil {
+		log.Fatal(err)
+	}
+	var b bytes.Buffer
+	if err := gen.Generate(&b, pkg, sp); err != nil {
+		log.Fatal(err)
+	}
+	if out == "" {
+		fmt.Print(b.String())
+		return
+	}
+	if err := os.WriteFile(out, b.Bytes(), 0644); err != nil {
+		log.Fatal(err)
+	}
+}
@@ -0,0 +1,40 @@
+package main
+
+import (
+	"flag"
+	"log"
+	"os"
+
+	"github.com/blackchip-org/scan/cmd/internal/cli"
+	"github.com/blackchip-org/scan/spec"
+)
+
+func main() {
+	var opts cli.Options
+	var specFile string
+	flag.StringVar(&specFile, "spec", "", "file with the lexer spec")
+	opts.Flags(flag.CommandLine)
+	flag.Parse()
+
+	if flag.NArg() != 1 || specFile == "" {
+		flag.Usage()
+		os.Exit(2)
+	}
+
+	log.SetFlags(0)
+	sp, err := spec.Load(specFile)
+	if err != nil {
+		log.Print(err)
+		os.Exit(2)
+	}
+	rules, err := sp.RuleSet()
+	if err != nil {
+		log.Print(err)
+		os.Exit(2)
+	}
+	tool := "scan"
+	if sp.Name != "" {
+		tool = "scan-" + sp.Name
+	}
+	os.Exit(cli.Run(tool, flag.Arg(0), rules, opts))
+}
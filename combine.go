@@ -0,0 +1,193 @@
+package scan
+
+// SeqRule matches each of its rules in order.
+type SeqRule struct {
+	rules []Rule
+}
+
+// Seq returns a rule that matches when all rules match one after the
+// other. If any rule does not match, the scanner is reset to where it was
+// before the sequence started.
+func Seq(rules ...Rule) SeqRule {
+	return SeqRule{rules: rules}
+}
+
+func (r SeqRule) Eval(s *Scanner) bool {
+	m := s.Mark()
+	for _, rule := range r.rules {
+		if !rule.Eval(s) {
+			s.Reset(m)
+			return false
+		}
+	}
+	return true
+}
+
+// AltRule matches the first of its rules that matches.
+type AltRule struct {
+	rules []Rule
+}
+
+// Alt returns a rule that tries each of rules in order and uses the first
+// one that matches. The scanner is reset after each rule that does not
+// match.
+func Alt(rules ...Rule) AltRule {
+	return AltRule{rules: rules}
+}
+
+func (r AltRule) Eval(s *Scanner) bool {
+	m := s.Mark()
+	for _, rule := range r.rules {
+		if rule.Eval(s) {
+			return true
+		}
+		s.Reset(m)
+	}
+	return false
+}
+
+// OptRule matches its rule zero or one times.
+type OptRule struct {
+	rule Rule
+}
+
+// Opt returns a rule that evaluates rule and always matches, even when
+// rule does not.
+func Opt(rule Rule) OptRule {
+	return OptRule{rule: rule}
+}
+
+func (r OptRule) Eval(s *Scanner) bool {
+	m := s.Mark()
+	if !r.rule.Eval(s) {
+		s.Reset(m)
+	}
+	return true
+}
+
+// ManyRule matches its rule as many times as possible.
+type ManyRule struct {
+	rule Rule
+	min  int
+}
+
+// Many returns a rule that evaluates rule until it no longer matches. It
+// matches zero or more times unless a minimum is set with WithMin.
+func Many(rule Rule) ManyRule {
+	return ManyRule{rule: rule}
+}
+
+// WithMin sets the number of times the rule must match. If it matches fewer
+// times, the scanner is reset to where it was before the first match.
+func (r ManyRule) WithMin(n int) ManyRule {
+	r.min = n
+	return r
+}
+
+func (r ManyRule) Eval(s *Scanner) bool {
+	start := s.Mark()
+	n := 0
+	for {
+		m := s.Mark()
+		if !r.rule.Eval(s) {
+			s.Reset(m)
+			break
+		}
+		n++
+		// Stop on a match that does not consume anything, otherwise this
+		// would loop forever.
+		if s.Pos == m.pos {
+			break
+		}
+	}
+	if n < r.min {
+		s.Reset(start)
+		return false
+	}
+	return true
+}
+
+// PredRule checks if its rule matches without consuming any input.
+type PredRule struct {
+	rule Rule
+	not  bool
+}
+
+// FollowedBy returns a rule that matches when rule matches at the current
+// position. The scanner is always reset so no input is consumed.
+func FollowedBy(rule Rule) PredRule {
+	return PredRule{rule: rule}
+}
+
+// NotFollowedBy returns a rule that matches when rule does not match at the
+// current position. The scanner is always reset so no input is consumed.
+// For example, a keyword should not be followed by a rune that could
+// continue an identifier:
+//
+//	Seq(Literal("if"), NotFollowedBy(NewClassRule(IsLetterDigitUnder)))
+func NotFollowedBy(rule Rule) PredRule {
+	return PredRule{rule: rule, not: true}
+}
+
+func (r PredRule) Eval(s *Scanner) bool {
+	m := s.Mark()
+	ok := r.rule.Eval(s)
+	s.Reset(m)
+	return ok != r.not
+}
+
+// LabelRule gives a name to its rule. The name is only used when describing
+// the rule, such as for the name of its production in EBNF.
+type LabelRule struct {
+	rule  Rule
+	label string
+}
+
+// Label returns a rule that matches the same text as rule and is described
+// with the name label.
+func Label(rule Rule, label string) LabelRule {
+	return LabelRule{rule: rule, label: label}
+}
+
+func (r LabelRule) Eval(s *Scanner) bool {
+	return r.rule.Eval(s)
+}
+
+// Resume continues the match of rule if it is a Resumer.
+func (r LabelRule) Resume(s *Scanner, data string) bool {
+	if rr, ok := r.rule.(Resumer); ok {
+		return rr.Resume(s, data)
+	}
+	return false
+}
+
+// TypedRule sets the type of the token matched by its rule.
+type TypedRule struct {
+	rule  Rule
+	type_ string
+}
+
+// Typed returns a rule that sets the token type to type_ when rule
+// matches.
+func Typed(rule Rule, type_ string) TypedRule {
+	return TypedRule{rule: rule, type_: type_}
+}
+
+func (r TypedRule) Eval(s *Scanner) bool {
+	if !r.rule.Eval(s) {
+		return false
+	}
+	s.Type = r.type_
+	return true
+}
+
+// Resume continues the match of rule if it is a Resumer and sets the type
+// again.
+func (r TypedRule) Resume(s *Scanner, data string) bool {
+	rr, ok := r.rule.(Resumer)
+	if !ok || !rr.Resume(s, data) {
+		return false
+	}
+	s.Type = r.type_
+	return true
+}
@@ -0,0 +1,99 @@
+package scan
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestCombinators(t *testing.T) {
+	keyword := Typed(
+		Seq(Literal("if", "for"), NotFollowedBy(NewClassRule(IsLetterDigitUnder))),
+		"keyword",
+	)
+	exp := Seq(Literal("e"), Opt(NewClassRule(IsSign)), Many(NewClassRule(IsDigit09)).WithMin(1))
+	num := Typed(Seq(Many(NewClassRule(IsDigit09)).WithMin(1), Opt(exp)), "num")
+	call := Typed(Seq(StandardIdentRule, FollowedBy(Literal("("))), "call")
+	rules := NewRuleSet(
+		SkipSpaceRule,
+		keyword,
+		num,
+		call,
+		StandardIdentRule,
+		Alt(Literal("=="), Literal("=")),
+		Literal("(", ")"),
+	)
+	tests := []Test{
+		NewTest("if iffy for", "if", 1, 1, "keyword").
+			And("iffy", 1, 4, IdentType).
+			And("for", 1, 9, "keyword"),
+		NewTest("12e+3 4e x", "12e+3", 1, 1, "num").
+			And("4", 1, 7, "num").
+			And("e", 1, 8, IdentType).
+			And("x", 1, 10, IdentType),
+		NewTest("f(a == b)", "f", 1, 1, "call").
+			And("(", 1, 2, "(").
+			And("a", 1, 3, IdentType).
+			And("==", 1, 5, "==").
+			And("b", 1, 8, IdentType).
+			And(")", 1, 9, ")"),
+	}
+	RunTests(t, rules, tests)
+}
+
+func TestCombinatorsReader(t *testing.T) {
+	// A sequence that fails after consuming input must put it back on the
+	// stream when reading from a reader
+	rules := NewRuleSet(
+		SkipSpaceRule,
+		Typed(Seq(Literal("a"), Literal("b"), Literal("c")), "abc"),
+		NewClassRule(IsLetter).WithType("letter"),
+	)
+	s := NewScanner("", strings.NewReader("abd abc"))
+	var have []string
+	for _, tok := range NewRunner(s, rules).All() {
+		have = append(have, tok.Type+":"+tok.Val)
+	}
+	want := "letter:a letter:b letter:d abc:abc"
+	if strings.Join(have, " ") != want {
+		t.Errorf("\n have: %v \n want: %v", strings.Join(have, " "), want)
+	}
+}
+
+func TestManyEmpty(t *testing.T) {
+	// A rule that matches without consuming input must not loop forever
+	rule := Many(Opt(Literal("x")))
+	s := NewScannerFromString("", "xxy")
+	if !rule.Eval(s) || s.This != 'y' {
+		t.Errorf("unexpected position: %v", s.Pos)
+	}
+}
+
+func TestCombinatorStart(t *testing.T) {
+	tests := []struct {
+		rule  Rule
+		ch    rune
+		start bool
+		known bool
+	}{
+		{Seq(Literal("ab"), Literal("c")), 'a', true, true},
+		{Seq(Literal("ab"), Literal("c")), 'c', false, true},
+		{Seq(Opt(Literal("a")), Literal("b")), 'b', true, false},
+		{Alt(Literal("a"), Literal("b")), 'b', true, true},
+		{Alt(Literal("a"), Opt(Literal("b"))), 'c', true, false},
+		{Many(Literal("a")), 'b', true, false},
+		{Many(Literal("a")).WithMin(1), 'b', false, true},
+		{FollowedBy(Literal("a")), 'a', true, true},
+		{NotFollowedBy(Literal("a")), 'b', true, false},
+		{Typed(Literal("a"), "t"), 'a', true, true},
+	}
+	for i, test := range tests {
+		start := StartOf(test.rule)
+		if (start != nil) != test.known {
+			t.Errorf("%v: start known should be %v", i, test.known)
+			continue
+		}
+		if start != nil && start(test.ch) != test.start {
+			t.Errorf("%v: start(%q) should be %v", i, test.ch, test.start)
+		}
+	}
+}
@@ -0,0 +1,44 @@
+package scan
+
+// WithBrackets tracks tokens with a type of open that must be followed by
+// a token with a type of close. Brackets that are still open at the end of
+// the input make the input incomplete. Use this option once for each pair
+// of brackets.
+func WithBrackets(open string, close string) RunnerOption {
+	return func(r *Runner) {
+		if r.brackets == nil {
+			r.brackets = make(map[string]string)
+		}
+		r.brackets[open] = close
+	}
+}
+
+// Complete returns false if the input ended in the middle of a construct
+// that could be finished with more input. This is the case when a token
+// has an IncompleteInput error or when a bracket set with WithBrackets has
+// not been closed. A REPL can use this to show a continuation prompt
+// instead of reporting an error.
+//
+// Only the tokens scanned so far are checked, which includes the Next
+// token. Call Complete once the end of the input has been reached.
+func (r *Runner) Complete() bool {
+	return !r.incomplete && len(r.open) == 0
+}
+
+// track updates the state used by Complete with a token that has been
+// scanned. A closing bracket only closes the bracket that was opened last.
+func (r *Runner) track(tok Token) {
+	if tok.IsIncomplete() {
+		r.incomplete = true
+	}
+	if len(r.brackets) == 0 {
+		return
+	}
+	if n := len(r.open); n > 0 && r.open[n-1] == tok.Type {
+		r.open = r.open[:n-1]
+		return
+	}
+	if close, ok := r.brackets[tok.Type]; ok {
+		r.open = append(r.open, close)
+	}
+}
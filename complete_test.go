@@ -0,0 +1,53 @@
+package scan
+
+import "testing"
+
+func TestComplete(t *testing.T) {
+	rules := NewRuleSet(
+		SkipSpaceRule,
+		NewCommentRule(Literal("/*"), Literal("*/")),
+		NewCommentRule(Literal("//"), Literal("\n")),
+		StrDoubleQuoteRule,
+		NewStrRule('`', '`').WithMultiline(true),
+		IntRule,
+		StandardIdentRule,
+		Literal("(", ")", "[", "]", "+"),
+	)
+	tests := []struct {
+		src      string
+		complete bool
+	}{
+		{"a + 1", true},
+		{"\"abc", false},
+		{"\"abc\ndef", true},
+		{"`abc\n", false},
+		{"a /* b", false},
+		{"a // b", true},
+		{"(a + [1", false},
+		{"(a + [1])", true},
+		{"(a + [1)", false},
+		{")", true},
+		{"(\"a", false},
+	}
+	for _, test := range tests {
+		r := NewRunner(NewScannerFromString("", test.src), rules,
+			WithBrackets("(", ")"),
+			WithBrackets("[", "]"),
+		)
+		r.All()
+		if r.Complete() != test.complete {
+			t.Errorf("%q: have %v, want %v", test.src, r.Complete(), test.complete)
+		}
+	}
+}
+
+func TestIncompleteError(t *testing.T) {
+	tok := NewRunner(NewScannerFromString("", "\"abc"), NewRuleSet(StrDoubleQuoteRule)).This
+	if !tok.IsIncomplete() || tok.Errs[0].Kind != IncompleteInput {
+		t.Errorf("expected incomplete error: %v", tok)
+	}
+	tok = NewRunner(NewScannerFromString("", "\"abc\n\""), NewRuleSet(StrDoubleQuoteRule)).This
+	if tok.IsIncomplete() || len(tok.Errs) != 1 || tok.Errs[0].Kind != InvalidInput {
+		t.Errorf("expected invalid error: %v", tok)
+	}
+}
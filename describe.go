@@ -0,0 +1,607 @@
+package scan
+
+import (
+	"fmt"
+	"io"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Describer is implemented by rules that can describe their configuration
+// and the text that they match.
+type Describer interface {
+	Describe() Desc
+}
+
+// Desc is the description of a rule. Name is the label given to the rule
+// with Label, if any. Kind is the kind of rule, such as "str" or "num", and
+// Type is the type of the tokens it emits, if known. Syntax is an
+// EBNF-style expression of the text matched. Rules are the descriptions of
+// the rules used by this rule and, for a rule set, Modes are the
+// descriptions of its modes with Name set to the name of the mode.
+type Desc struct {
+	Name   string
+	Kind   string
+	Type   string
+	Syntax string
+	Attrs  []Attr
+	Rules  []Desc
+	Modes  []Desc
+}
+
+// Attr is a configuration setting of a rule.
+type Attr struct {
+	Name  string
+	Value string
+}
+
+// Describe returns the description of rule if it implements Describer.
+// Otherwise, the description only contains the Go type of the rule.
+func Describe(rule Rule) Desc {
+	if d, ok := rule.(Describer); ok {
+		return d.Describe()
+	}
+	t := fmt.Sprintf("%T", rule)
+	return Desc{Kind: "rule", Syntax: "(* " + t + " *)", Attrs: []Attr{{"go", t}}}
+}
+
+func describeAll(rules []Rule) []Desc {
+	var ds []Desc
+	for _, rule := range rules {
+		ds = append(ds, Describe(rule))
+	}
+	return ds
+}
+
+func (d Desc) with(name string, value any) Desc {
+	d.Attrs = append(d.Attrs, Attr{Name: name, Value: fmt.Sprint(value)})
+	return d
+}
+
+// Attr returns the value of the named attribute and true if found.
+func (d Desc) Attr(name string) (string, bool) {
+	for _, a := range d.Attrs {
+		if a.Name == name {
+			return a.Value, true
+		}
+	}
+	return "", false
+}
+
+// nonASCIISamples are used to check if a class contains runes that are not
+// ASCII.
+var nonASCIISamples = []rune{'é', 'λ', 'ж', '中', '٣', '€', ' ', ' ', '😀'}
+
+// namedClasses are the predefined classes that contain runes that are not
+// ASCII. They are described by name instead of by a partial set.
+var namedClasses = []struct {
+	name  string
+	class Class
+}{
+	{"IsCurrency", IsCurrency},
+	{"IsDigit", IsDigit},
+	{"IsLetter", IsLetter},
+	{"IsLetterUnder", IsLetterUnder},
+	{"IsLetterDigitUnder", IsLetterDigitUnder},
+	{"IsPrintable", IsPrintable},
+	{"IsRune8", IsRune8},
+	{"IsRune16", IsRune16},
+	{"IsSpace", IsSpace},
+}
+
+// DescribeClass returns an EBNF-style expression for class c. As a class is
+// a function, it is described by sampling: each ASCII rune is checked and
+// a few other runes are checked to see if it also contains runes that are
+// not ASCII. Such a class is shown by name, such as "IsLetterUnder", if it
+// samples the same as one of the predefined classes. Otherwise, it is shown
+// with an ellipsis, such as "[A-Z_a-z…]". A class that contains most runes
+// is shown as a negation, such as "[^"]".
+func DescribeClass(c Class) string {
+	var in, out []rune
+	for ch := rune(0); ch < utf8.RuneSelf; ch++ {
+		if c(ch) {
+			in = append(in, ch)
+		} else {
+			out = append(out, ch)
+		}
+	}
+	nonASCII := slices.ContainsFunc(nonASCIISamples, c)
+	allNonASCII := !slices.ContainsFunc(nonASCIISamples, Not(c))
+	if nonASCII {
+		for _, nc := range namedClasses {
+			if sameClass(c, nc.class) {
+				return nc.name
+			}
+		}
+	}
+	switch {
+	case len(out) == 0 && allNonASCII:
+		return "any"
+	case len(in) == 1 && !nonASCII:
+		return quoteRune(in[0])
+	case len(out) > 0 && len(in) > len(out) && nonASCII:
+		return "[^" + bracketRanges(out) + "]"
+	case nonASCII:
+		return "[" + bracketRanges(in) + "…]"
+	}
+	return "[" + bracketRanges(in) + "]"
+}
+
+// sameClass returns true if a and b agree on the runes up to U+07FF, on
+// the samples of other runes, and at the limits of 8 and 16-bit runes.
+func sameClass(a, b Class) bool {
+	for ch := rune(0); ch < 0x800; ch++ {
+		if a(ch) != b(ch) {
+			return false
+		}
+	}
+	for _, ch := range nonASCIISamples {
+		if a(ch) != b(ch) {
+			return false
+		}
+	}
+	for _, ch := range []rune{0xffff, 0x10000, unicode.MaxRune} {
+		if a(ch) != b(ch) {
+			return false
+		}
+	}
+	return true
+}
+
+// isEmptyClass returns true if c does not contain any of the runes
+// sampled by DescribeClass.
+func isEmptyClass(c Class) bool {
+	for ch := rune(0); ch < utf8.RuneSelf; ch++ {
+		if c(ch) {
+			return false
+		}
+	}
+	return !slices.ContainsFunc(nonASCIISamples, c)
+}
+
+// bracketRanges formats sorted runes for use in a bracket expression.
+// Three or more runes in a row are shown as a range.
+func bracketRanges(rs []rune) string {
+	var b strings.Builder
+	for i := 0; i < len(rs); {
+		j := i
+		for j+1 < len(rs) && rs[j+1] == rs[j]+1 {
+			j++
+		}
+		if j-i >= 2 {
+			b.WriteString(bracketRune(rs[i]) + "-" + bracketRune(rs[j]))
+		} else {
+			for k := i; k <= j; k++ {
+				b.WriteString(bracketRune(rs[k]))
+			}
+		}
+		i = j + 1
+	}
+	return b.String()
+}
+
+func bracketRune(ch rune) string {
+	switch ch {
+	case '\\', ']', '^', '-':
+		return `\` + string(ch)
+	}
+	if unicode.IsPrint(ch) {
+		return string(ch)
+	}
+	q := strconv.QuoteRune(ch)
+	return q[1 : len(q)-1]
+}
+
+func quoteLit(s string) string {
+	return strconv.Quote(s)
+}
+
+func quoteRune(ch rune) string {
+	return strconv.Quote(string(ch))
+}
+
+// group wraps an expression with alternatives in parentheses.
+func group(s string) string {
+	if strings.Contains(s, " | ") {
+		return "( " + s + " )"
+	}
+	return s
+}
+
+func alt(ds []Desc) string {
+	var ss []string
+	for _, d := range ds {
+		ss = append(ss, d.Syntax)
+	}
+	return strings.Join(ss, " | ")
+}
+
+// EBNF returns the description as EBNF-style productions. A rule set has a
+// "token" production that lists a production for each of its rules. Each
+// mode is listed after a comment with the name of the mode.
+func (d Desc) EBNF() string {
+	var b strings.Builder
+	d.ebnf(&b)
+	for _, m := range d.Modes {
+		fmt.Fprintf(&b, "\n(* mode %v *)\n", m.Name)
+		m.ebnf(&b)
+	}
+	return b.String()
+}
+
+func (d Desc) ebnf(b *strings.Builder) {
+	if d.Kind != "rules" {
+		fmt.Fprintf(b, "%v = %v .\n", productionName(d), d.Syntax)
+		return
+	}
+	names := productionNames(d.Rules)
+	fmt.Fprintf(b, "token = %v .\n", strings.Join(names, " | "))
+	for i, r := range d.Rules {
+		fmt.Fprintf(b, "%v = %v .\n", names[i], r.Syntax)
+	}
+}
+
+// productionName returns the label of the rule, the type of its tokens, or
+// the kind of rule, in that order, as the name of its production.
+func productionName(d Desc) string {
+	if d.Name != "" {
+		return d.Name
+	}
+	if d.Type != "" {
+		return d.Type
+	}
+	return d.Kind
+}
+
+func productionNames(ds []Desc) []string {
+	var names []string
+	for _, d := range ds {
+		name := productionName(d)
+		for i := 2; slices.Contains(names, name); i++ {
+			name = fmt.Sprintf("%v_%v", productionName(d), i)
+		}
+		names = append(names, name)
+	}
+	return names
+}
+
+// WriteGrammar writes a Markdown document to w that has title as its
+// heading followed by the EBNF productions and the table of rules from the
+// description of rules.
+func WriteGrammar(w io.Writer, title string, rules RuleSet) error {
+	d := rules.Describe()
+	_, err := fmt.Fprintf(w, "# %v\n\nThis grammar is generated from the rule set.\n\n```ebnf\n%v```\n\n%v",
+		title, d.EBNF(), d.Markdown())
+	return err
+}
+
+// Markdown returns the description as a Markdown table with a row for each
+// rule. Rules are named as their productions are in EBNF. Each mode has its
+// own table after a heading with the name of the mode.
+func (d Desc) Markdown() string {
+	var b strings.Builder
+	d.markdown(&b)
+	for _, m := range d.Modes {
+		fmt.Fprintf(&b, "\n### Mode `%v`\n\n", m.Name)
+		m.markdown(&b)
+	}
+	return b.String()
+}
+
+func (d Desc) markdown(b *strings.Builder) {
+	rules := []Desc{d}
+	if d.Kind == "rules" {
+		rules = d.Rules
+	}
+	names := productionNames(rules)
+	b.WriteString("| Rule | Kind | Type | Syntax | Options |\n")
+	b.WriteString("|------|------|------|--------|---------|\n")
+	for i, r := range rules {
+		var opts []string
+		for _, a := range r.Attrs {
+			opts = append(opts, fmt.Sprintf("%v: %v", a.Name, mdCode(a.Value)))
+		}
+		fmt.Fprintf(b, "| %v | %v | %v | %v | %v |\n",
+			names[i], r.Kind, r.Type, mdCode(r.Syntax), strings.Join(opts, "<br>"))
+	}
+}
+
+// mdCode formats s as inline code that can be used in a table.
+func mdCode(s string) string {
+	if s == "" {
+		return ""
+	}
+	s = strings.ReplaceAll(s, "|", `\|`)
+	if strings.Contains(s, "`") {
+		return "`` " + s + " ``"
+	}
+	return "`" + s + "`"
+}
+
+func (r AltRule) Describe() Desc {
+	rules := describeAll(r.rules)
+	return Desc{Kind: "alt", Syntax: alt(rules), Rules: rules}
+}
+
+func (r CharEncRule) Describe() Desc {
+	var from []rune
+	for ch := range r.charmap {
+		from = append(from, ch)
+	}
+	slices.Sort(from)
+	var syntax, encs []string
+	for _, ch := range from {
+		syntax = append(syntax, quoteRune(ch))
+		encs = append(encs, fmt.Sprintf("%c=%v", ch, quoteRune(r.charmap[ch])))
+	}
+	return Desc{Kind: "charEnc", Syntax: strings.Join(syntax, " | ")}.
+		with("map", strings.Join(encs, " "))
+}
+
+func (r ClassRule) Describe() Desc {
+	return Desc{Kind: "class", Type: r.type_, Syntax: DescribeClass(r.isClass)}
+}
+
+func (r CommentRule) Describe() Desc {
+	begin, end := Describe(r.begin), Describe(r.end)
+	d := Desc{
+		Kind:   "comment",
+		Syntax: group(begin.Syntax) + " { any } " + group(end.Syntax),
+	}
+	if r.keeps() {
+		d.Type = CommentType
+	}
+	return d.with("keep", r.keeps())
+}
+
+func (r HexEncRule) Describe() Desc {
+	syntax := []string{quoteRune(r.flag)}
+	for range r.digits {
+		syntax = append(syntax, DescribeClass(IsDigit0F))
+	}
+	return Desc{Kind: "hexEnc", Syntax: strings.Join(syntax, " ")}.
+		with("digits", r.digits).
+		with("asByte", r.asByte)
+}
+
+func (r IdentRule) Describe() Desc {
+	d := Desc{
+		Kind:   "ident",
+		Type:   IdentType,
+		Syntax: DescribeClass(r.isHead) + " { " + DescribeClass(r.isTail) + " }",
+	}
+	if len(r.keywords) > 0 {
+		var ks []string
+		for k := range r.keywords {
+			ks = append(ks, k)
+		}
+		slices.Sort(ks)
+		d = d.with("keywords", strings.Join(ks, " "))
+	}
+	return d
+}
+
+// Describe returns the description of the rule with Name set to the label.
+func (r LabelRule) Describe() Desc {
+	d := Describe(r.rule)
+	d.Name = r.label
+	return d
+}
+
+// Describe lists the literals in sorted order. The type of each token is
+// the literal itself.
+func (r LiteralRule) Describe() Desc {
+	var lits []string
+	var walk func(*trieNode, string)
+	walk = func(n *trieNode, prefix string) {
+		for ch, child := range n.children {
+			lit := prefix + string(ch)
+			if child.leaf {
+				lits = append(lits, lit)
+			}
+			walk(child, lit)
+		}
+	}
+	walk(r.lits, "")
+	slices.Sort(lits)
+	var syntax []string
+	for _, lit := range lits {
+		syntax = append(syntax, quoteLit(lit))
+	}
+	return Desc{Kind: "literal", Syntax: strings.Join(syntax, " | ")}
+}
+
+func (r ManyRule) Describe() Desc {
+	rule := Describe(r.rule)
+	syntax := strings.Repeat(group(rule.Syntax)+" ", r.min) + "{ " + rule.Syntax + " }"
+	return Desc{Kind: "many", Syntax: syntax, Rules: []Desc{rule}}.with("min", r.min)
+}
+
+func (r ModeRule) Describe() Desc {
+	rule := Describe(r.rule)
+	d := Desc{Name: rule.Name, Kind: "mode", Type: rule.Type, Syntax: rule.Syntax, Rules: []Desc{rule}}
+	switch {
+	case r.pop:
+		d = d.with("pop", true)
+	case r.push != "":
+		d = d.with("push", r.push)
+	}
+	return d
+}
+
+func (r NumRule) Describe() Desc {
+	d := Desc{Kind: "num", Type: r.intType}
+	digit := DescribeClass(r.isDigit)
+	digits := digit + " { " + digit + " }"
+	d = d.with("digit", digit)
+
+	var syntax []string
+	if !isEmptyClass(r.isSign) {
+		syntax = append(syntax, "[ "+DescribeClass(r.isSign)+" ]")
+		d = d.with("sign", DescribeClass(r.isSign))
+	}
+	if r.prefixRule != Rule(TrueRule) {
+		prefix := Describe(r.prefixRule)
+		syntax = append(syntax, group(prefix.Syntax))
+		d.Rules = append(d.Rules, prefix)
+		d = d.with("prefix", prefix.Syntax)
+	}
+	if !isEmptyClass(r.isDigitSep) {
+		sep := DescribeClass(r.isDigitSep)
+		digits = digit + " { [ " + sep + " ] " + digit + " }"
+		if r.leadingDigitSepAllowed {
+			syntax = append(syntax, "[ "+sep+" ]")
+		}
+		d = d.with("digitSep", sep)
+	}
+	if !isEmptyClass(r.isDecSep) {
+		dec := DescribeClass(r.isDecSep)
+		if r.emptyPartsAllowed {
+			syntax = append(syntax, "( "+digits+" [ "+dec+" [ "+digits+" ] ] | "+dec+" "+digits+" )")
+		} else {
+			syntax = append(syntax, digits+" [ "+dec+" "+digits+" ]")
+		}
+		d = d.with("decSep", dec)
+	} else {
+		syntax = append(syntax, digits)
+	}
+	if !isEmptyClass(r.isExp) {
+		exp := "[ " + DescribeClass(r.isExp) + " "
+		d = d.with("exp", DescribeClass(r.isExp))
+		if !isEmptyClass(r.isExpSign) {
+			exp += "[ " + DescribeClass(r.isExpSign) + " ] "
+			d = d.with("expSign", DescribeClass(r.isExpSign))
+		}
+		syntax = append(syntax, exp+digits+" ]")
+	}
+	if !isEmptyClass(r.isDecSep) || !isEmptyClass(r.isExp) {
+		d = d.with("realType", r.realType)
+	}
+	if len(r.suffixRules) > 0 {
+		suffix := describeAll(r.suffixRules)
+		syntax = append(syntax, "[ "+alt(suffix)+" ]")
+		d.Rules = append(d.Rules, suffix...)
+	}
+	d.Syntax = strings.Join(syntax, " ")
+	return d.
+		with("leadingDigitSep", r.leadingDigitSepAllowed).
+		with("leadingZero", r.leadingZeroAllowed).
+		with("emptyParts", r.emptyPartsAllowed)
+}
+
+func (r OctEncRule) Describe() Desc {
+	digit := DescribeClass(IsDigit07)
+	return Desc{Kind: "octEnc", Syntax: digit + " " + digit + " " + digit}
+}
+
+func (r OptRule) Describe() Desc {
+	rule := Describe(r.rule)
+	return Desc{Kind: "opt", Syntax: "[ " + rule.Syntax + " ]", Rules: []Desc{rule}}
+}
+
+// Describe uses "&" for FollowedBy and "!" for NotFollowedBy as found in
+// parsing expression grammars.
+func (r PredRule) Describe() Desc {
+	rule := Describe(r.rule)
+	op := "&"
+	if r.not {
+		op = "!"
+	}
+	return Desc{Kind: "pred", Syntax: op + group(rule.Syntax), Rules: []Desc{rule}}
+}
+
+func (r RegexRule) Describe() Desc {
+	return Desc{Kind: "regex", Type: r.type_, Syntax: "/" + r.pattern + "/"}
+}
+
+// Describe returns a description with the descriptions of each rule in
+// Rules and of each mode, sorted by name, in Modes.
+func (r RuleSet) Describe() Desc {
+	rules := describeAll(r.rules)
+	d := Desc{Kind: "rules", Syntax: alt(rules), Rules: rules}
+	var names []string
+	for name := range r.modes {
+		names = append(names, name)
+	}
+	slices.Sort(names)
+	for _, name := range names {
+		m := r.modes[name].Describe()
+		m.Name = name
+		d.Modes = append(d.Modes, m)
+	}
+	if r.longest {
+		d = d.with("longestMatch", true)
+	}
+	return d
+}
+
+func (r SeqRule) Describe() Desc {
+	rules := describeAll(r.rules)
+	var syntax []string
+	for _, rule := range rules {
+		syntax = append(syntax, group(rule.Syntax))
+	}
+	return Desc{Kind: "seq", Syntax: strings.Join(syntax, " "), Rules: rules}
+}
+
+func (r StrRule) Describe() Desc {
+	d := Desc{Kind: "str", Type: r.type_}
+	if d.Type == "" {
+		d.Type = StrType
+	}
+	not := []rune{r.end}
+	if r.escape != 0 {
+		not = append(not, r.escape)
+	}
+	if !r.multiline {
+		not = append(not, '\n')
+	}
+	slices.Sort(not)
+	char := "[^" + bracketRanges(slices.Compact(not)) + "]"
+	if r.escape != 0 {
+		esc := []string{quoteRune(r.end)}
+		if r.escape != r.end {
+			esc = append(esc, quoteRune(r.escape))
+		}
+		escapes := r.escapeRules.Describe()
+		if escapes.Syntax != "" {
+			esc = append(esc, escapes.Syntax)
+		}
+		char += " | " + quoteRune(r.escape) + " ( " + strings.Join(esc, " | ") + " )"
+		d.Rules = escapes.Rules
+		d = d.with("escape", quoteRune(r.escape))
+	}
+	end := quoteRune(r.end)
+	if r.optTerm {
+		end = "[ " + end + " ]"
+	}
+	d.Syntax = quoteRune(r.begin) + " { " + char + " } " + end
+	if r.maxLen > 0 {
+		d = d.with("maxLen", r.maxLen)
+	}
+	return d.
+		with("multiline", r.multiline).
+		with("optionalTerminator", r.optTerm).
+		with("nesting", r.nesting)
+}
+
+func (r trueRule) Describe() Desc {
+	return Desc{Kind: "true"}
+}
+
+func (r TypedRule) Describe() Desc {
+	rule := Describe(r.rule)
+	return Desc{Name: rule.Name, Kind: "typed", Type: r.type_, Syntax: rule.Syntax, Rules: []Desc{rule}}
+}
+
+func (r WhileRule) Describe() Desc {
+	class := DescribeClass(r.isClass)
+	d := Desc{Kind: "while", Syntax: class + " { " + class + " }"}
+	if r.keep {
+		d.Type = r.type_
+	}
+	return d.with("keep", r.keep)
+}
@@ -0,0 +1,126 @@
+package scan
+
+import (
+	"strings"
+	"testing"
+)
+
+type opaqueRule struct{}
+
+func (opaqueRule) Eval(*Scanner) bool { return false }
+
+func TestDescribeClass(t *testing.T) {
+	tests := []struct {
+		name  string
+		class Class
+		desc  string
+	}{
+		{"any", IsAny, "any"},
+		{"rune", Rune('x'), `"x"`},
+		{"not", Not(Rune('\n')), `[^\n]`},
+		{"escaped", Rune('-', ']'), `[\-\]]`},
+		{"range", Range('a', 'f'), `[a-f]`},
+		{"named", IsLetterUnder, `IsLetterUnder`},
+		{"rune8", IsRune8, `IsRune8`},
+		{"unicode", Or(IsLetter, Rune('$')), `[$A-Za-z…]`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			desc := DescribeClass(test.class)
+			if desc != test.desc {
+				t.Errorf("\n have: %v \n want: %v", desc, test.desc)
+			}
+		})
+	}
+}
+
+func TestDescribeNamedClasses(t *testing.T) {
+	for _, nc := range namedClasses {
+		if desc := DescribeClass(nc.class); desc != nc.name {
+			t.Errorf("\n have: %v \n want: %v", desc, nc.name)
+		}
+	}
+}
+
+func TestDescribe(t *testing.T) {
+	tests := []struct {
+		name   string
+		rule   Rule
+		syntax string
+	}{
+		{"literal", Literal("+", "-"), `"+" | "-"`},
+		{"int", IntRule, `[0-9] { [0-9] }`},
+		{"regex", NewRegexRule(`v\d+`, "version"), `/v\d+/`},
+		{"opaque", opaqueRule{}, `(* scan.opaqueRule *)`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			syntax := Describe(test.rule).Syntax
+			if syntax != test.syntax {
+				t.Errorf("\n have: %v \n want: %v", syntax, test.syntax)
+			}
+		})
+	}
+}
+
+func describeRules() Desc {
+	return Describe(NewRuleSet(
+		IntRule,
+		Literal("+", "-"),
+		Label(NewModeRule(Literal("{")).WithPush("x"), "open"),
+	).WithMode("x", NewRuleSet(
+		Label(NewModeRule(Literal("}")).WithPop(true), "close"),
+		IntRule,
+	)))
+}
+
+func TestDescEBNF(t *testing.T) {
+	want := strings.Join([]string{
+		`token = int | literal | open .`,
+		`int = [0-9] { [0-9] } .`,
+		`literal = "+" | "-" .`,
+		`open = "{" .`,
+		``,
+		`(* mode x *)`,
+		`token = close | int .`,
+		`close = "}" .`,
+		`int = [0-9] { [0-9] } .`,
+		``,
+	}, "\n")
+	have := describeRules().EBNF()
+	if have != want {
+		t.Errorf("\n have: \n%v \n want: \n%v", have, want)
+	}
+}
+
+func TestWriteGrammar(t *testing.T) {
+	var b strings.Builder
+	if err := WriteGrammar(&b, "Test Grammar", NewRuleSet(IntRule)); err != nil {
+		t.Fatal(err)
+	}
+	have := b.String()
+	for _, want := range []string{
+		"# Test Grammar\n\n",
+		"```ebnf\ntoken = int .\nint = [0-9] { [0-9] } .\n```\n\n",
+		"| int | num | int | `[0-9] { [0-9] }` |",
+	} {
+		if !strings.Contains(have, want) {
+			t.Errorf("missing %q in:\n%v", want, have)
+		}
+	}
+}
+
+func TestDescMarkdown(t *testing.T) {
+	have := describeRules().Markdown()
+	for _, want := range []string{
+		"| Rule | Kind | Type | Syntax | Options |\n",
+		"| literal | literal |  | `\"+\" \\| \"-\"` |  |\n",
+		"| open | mode |  | `\"{\"` | push: `x` |\n",
+		"### Mode `x`\n",
+		"| close | mode |  | `\"}\"` | pop: `true` |\n",
+	} {
+		if !strings.Contains(have, want) {
+			t.Errorf("missing %q in:\n%v", want, have)
+		}
+	}
+}
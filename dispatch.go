@@ -0,0 +1,190 @@
+package scan
+
+import "unicode/utf8"
+
+// Starter is implemented by rules that can report which runes a match may
+// begin with. A rule set uses this to only evaluate the rules that could
+// match the current rune. Start returns nil if the runes are not known.
+type Starter interface {
+	Start() Class
+}
+
+// dispatch holds, for each ASCII rune, the rules that may match when that
+// rune is the current rune along with the index of each rule in the rule
+// set. Rules that do not implement Starter are included for every rune.
+type dispatch struct {
+	ascii  [128][]Rule
+	index  [128][]int
+	starts []Class
+}
+
+func newDispatch(rules []Rule) *dispatch {
+	d := &dispatch{starts: make([]Class, len(rules))}
+	for i, rule := range rules {
+		d.starts[i] = StartOf(rule)
+	}
+	for ch := rune(0); ch < 128; ch++ {
+		for i, rule := range rules {
+			if d.starts[i] == nil || d.starts[i](ch) {
+				d.ascii[ch] = append(d.ascii[ch], rule)
+				d.index[ch] = append(d.index[ch], i)
+			}
+		}
+	}
+	return d
+}
+
+// candidates returns the rules that should be evaluated when ch is the
+// current rune. If index is not nil, it contains the index of each rule in
+// the rule set. If starts is not nil, a rule should be skipped when its
+// start class is not nil and does not contain ch.
+func (r RuleSet) candidates(ch rune) (rules []Rule, index []int, starts []Class) {
+	switch {
+	case r.dispatch == nil || ch == EndOfText:
+		return r.rules, nil, nil
+	case ch >= 0 && ch < 128:
+		return r.dispatch.ascii[ch], r.dispatch.index[ch], nil
+	}
+	return r.rules, nil, r.dispatch.starts
+}
+
+// StartOf returns the start class for rule if it implements Starter, or nil
+// otherwise.
+func StartOf(rule Rule) Class {
+	if st, ok := rule.(Starter); ok {
+		return st.Start()
+	}
+	return nil
+}
+
+func startOfAll(rules []Rule) Class {
+	var cs []Class
+	for _, rule := range rules {
+		c := StartOf(rule)
+		if c == nil {
+			return nil
+		}
+		cs = append(cs, c)
+	}
+	return Or(cs...)
+}
+
+// runeKeys returns a class of the runes used as keys in m.
+func runeKeys[V any](m map[rune]V) Class {
+	rs := make([]rune, 0, len(m))
+	for ch := range m {
+		rs = append(rs, ch)
+	}
+	return Rune(rs...)
+}
+
+// Start returns a class that contains the start runes of all rules, or nil
+// if any rule does not implement Starter.
+func (r AltRule) Start() Class {
+	return startOfAll(r.rules)
+}
+
+func (r CharEncRule) Start() Class {
+	return runeKeys(r.charmap)
+}
+
+func (r ClassRule) Start() Class {
+	return r.isClass
+}
+
+func (r CommentRule) Start() Class {
+	return r.begin.Start()
+}
+
+func (r HexEncRule) Start() Class {
+	return Rune(r.flag)
+}
+
+func (r IdentRule) Start() Class {
+	return r.isHead
+}
+
+func (r LabelRule) Start() Class {
+	return StartOf(r.rule)
+}
+
+func (r LiteralRule) Start() Class {
+	return runeKeys(r.lits.children)
+}
+
+// Start returns nil when the rule can match without consuming input.
+func (r ManyRule) Start() Class {
+	if r.min == 0 {
+		return nil
+	}
+	return StartOf(r.rule)
+}
+
+func (r ModeRule) Start() Class {
+	return StartOf(r.rule)
+}
+
+func (r NumRule) Start() Class {
+	if r.prefixRule != Rule(TrueRule) {
+		prefix := StartOf(r.prefixRule)
+		if prefix == nil {
+			return nil
+		}
+		return Or(r.isSign, prefix)
+	}
+	start := Or(r.isSign, r.isDigit, r.isDecSep)
+	if r.leadingDigitSepAllowed {
+		start = Or(start, r.isDigitSep)
+	}
+	return start
+}
+
+func (r OctEncRule) Start() Class {
+	return IsDigit07
+}
+
+// Start returns nil for NotFollowedBy as any rune that does not start the
+// rule could match.
+func (r PredRule) Start() Class {
+	if r.not {
+		return nil
+	}
+	return StartOf(r.rule)
+}
+
+// Start returns the first rune of the literal prefix of the pattern, or nil
+// if the pattern does not have one.
+func (r RegexRule) Start() Class {
+	if r.prefix == "" {
+		return nil
+	}
+	ch, _ := utf8.DecodeRuneInString(r.prefix)
+	return Rune(ch)
+}
+
+// Start returns a class that contains the start runes of all rules in the
+// set, or nil if any rule does not implement Starter.
+func (r RuleSet) Start() Class {
+	return startOfAll(r.rules)
+}
+
+// Start returns the start runes of the first rule in the sequence. A first
+// rule that can match without consuming input should return nil.
+func (r SeqRule) Start() Class {
+	if len(r.rules) == 0 {
+		return nil
+	}
+	return StartOf(r.rules[0])
+}
+
+func (r StrRule) Start() Class {
+	return Rune(r.begin)
+}
+
+func (r TypedRule) Start() Class {
+	return StartOf(r.rule)
+}
+
+func (r WhileRule) Start() Class {
+	return r.isClass
+}
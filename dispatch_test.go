@@ -0,0 +1,96 @@
+package scan
+
+import (
+	"strings"
+	"testing"
+)
+
+func newDispatchTestRules() RuleSet {
+	return NewRuleSet(
+		SkipSpaceRule,
+		NewCommentRule(Literal("//"), Literal("\n")),
+		NewCommentRule(Literal("/*"), Literal("*/")),
+		StrDoubleQuoteRule,
+		StrSingleQuoteRule,
+		Hex0xRule,
+		Bin0bRule,
+		RealExpRule.WithDigitSep(Rune('_')),
+		StandardIdentRule.WithKeywords("func", "return", "if", "else"),
+		Literal("+", "-", "*", "/", "(", ")", "{", "}", ",", ".", ":=", "=", "==", ";"),
+	)
+}
+
+var dispatchTestSrc = strings.Repeat(`
+func main() {
+	// comment
+	x := 0x1f + 0b1010 * 1_000.5e-3 /* block */
+	if x == 'a' {
+		return "hello, 世界"
+	}
+	π := 3.14159
+	¿
+}
+`, 100)
+
+func TestDispatch(t *testing.T) {
+	rules := newDispatchTestRules()
+	linear := rules
+	linear.dispatch = nil
+
+	have := NewRunner(NewScannerFromString("", dispatchTestSrc), rules).All()
+	want := NewRunner(NewScannerFromString("", dispatchTestSrc), linear).All()
+	if len(have) != len(want) {
+		t.Fatalf("\n have: %v tokens \n want: %v tokens", len(have), len(want))
+	}
+	for i := range have {
+		if !have[i].Equal(want[i]) {
+			t.Fatalf("\n have: %v \n want: %v", have[i], want[i])
+		}
+	}
+}
+
+func TestStart(t *testing.T) {
+	tests := []struct {
+		name  string
+		rule  Rule
+		match string
+		skip  string
+	}{
+		{"literal", Literal("+", "-=", "if"), "+-i", "=fx"},
+		{"hex0x", Hex0xRule, "0", "x1a"},
+		{"signed", SignedRealRule, "+-.0", "ex"},
+		{"str", StrDoubleQuoteRule, `"`, `'`},
+		{"comment", NewCommentRule(Literal("#"), Literal("\n")), "#", "/"},
+		{"ruleset", NewRuleSet(StrSingleQuoteRule, IntRule), "'0", `"a`},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			start := StartOf(test.rule)
+			for _, ch := range test.match {
+				if !start(ch) {
+					t.Errorf("expected match: %c", ch)
+				}
+			}
+			for _, ch := range test.skip {
+				if start(ch) {
+					t.Errorf("unexpected match: %c", ch)
+				}
+			}
+		})
+	}
+}
+
+func BenchmarkDispatch(b *testing.B) {
+	rules := newDispatchTestRules()
+	for i := 0; i < b.N; i++ {
+		NewRunner(NewScannerFromString("", dispatchTestSrc), rules).All()
+	}
+}
+
+func BenchmarkLinear(b *testing.B) {
+	rules := newDispatchTestRules()
+	rules.dispatch = nil
+	for i := 0; i < b.N; i++ {
+		NewRunner(NewScannerFromString("", dispatchTestSrc), rules).All()
+	}
+}
@@ -0,0 +1,163 @@
+package scan
+
+import (
+	"errors"
+	"io"
+	"iter"
+	"slices"
+	"unicode/utf8"
+)
+
+// ErrIncompleteRune is the cause of the error reported when the input fed
+// to a Feeder ends in the middle of a UTF-8 encoded rune.
+var ErrIncompleteRune = errors.New("incomplete UTF-8 encoding")
+
+// Feeder scans input that is pushed to it as it arrives instead of pulling
+// from a reader. This is useful with interactive or network input where a
+// read would block.
+//
+// A token is only returned once it is known to be complete. As rules look
+// ahead by a rune, a token is held until at least two runes past its end
+// have been fed or until the Feeder is closed.
+//
+// The scan runs as a coroutine which is suspended whenever it needs more
+// input than has been fed. Each rune is read once and the scan resumes
+// where it left off on the next call to Feed. Call Close to release the
+// coroutine when done with a Feeder, even if the input is abandoned.
+type Feeder struct {
+	rules   RuleSet
+	s       Scanner
+	pos     Pos
+	head    []byte
+	chunk   []byte
+	partial []byte
+	closed  bool
+	yield   func(Token, bool) bool
+	next    func() (Token, bool, bool)
+	stop    func()
+}
+
+// NewFeeder returns a Feeder that scans the input fed to it using rules.
+// The name is used in the positions of the tokens.
+func NewFeeder(name string, rules RuleSet) *Feeder {
+	f := &Feeder{
+		rules: rules,
+		pos:   NewPos(name),
+	}
+	f.next, f.stop = iter.Pull2(f.tokens)
+	return f
+}
+
+// Feed appends data to the input and returns the tokens that are now
+// complete. Nil is returned if the Feeder has been closed. The data is
+// no longer referenced once Feed returns.
+func (f *Feeder) Feed(data []byte) []Token {
+	if f.closed {
+		return nil
+	}
+	if len(f.partial) > 0 {
+		// Complete the rune that was split by the previous call
+		for len(data) > 0 && !utf8.FullRune(f.partial) {
+			f.partial = append(f.partial, data[0])
+			data = data[1:]
+		}
+		if !utf8.FullRune(f.partial) {
+			return nil
+		}
+		f.head, f.partial = f.partial, nil
+	}
+	for i := len(data) - 1; i >= 0 && i >= len(data)-utf8.UTFMax; i-- {
+		if utf8.RuneStart(data[i]) {
+			if !utf8.FullRune(data[i:]) {
+				f.partial = slices.Clone(data[i:])
+				data = data[:i]
+			}
+			break
+		}
+	}
+	f.chunk = data
+	toks := f.scan()
+	f.head, f.chunk = nil, nil
+	return toks
+}
+
+// Close marks the end of the input and returns the remaining tokens. If the
+// input ends with an incomplete rune, an illegal token with an
+// ErrIncompleteRune error is returned last.
+func (f *Feeder) Close() []Token {
+	if f.closed {
+		return nil
+	}
+	f.closed = true
+	toks := f.scan()
+	f.stop()
+	if f.partial != nil {
+		pos := f.s.Pos
+		end := pos
+		end.Col++
+		end.Offset += len(f.partial)
+		toks = append(toks, Token{
+			Lit:  string(f.partial),
+			Type: IllegalType,
+			Pos:  pos,
+			End:  end,
+			Errs: Errors{{
+				Pos:   pos,
+				End:   end,
+				Cause: ErrIncompleteRune,
+				Kind:  IncompleteInput,
+				Code:  "utf8.incomplete",
+			}},
+		})
+	}
+	return toks
+}
+
+// scan resumes the coroutine and returns the tokens completed before it
+// needs more input or reaches the end of the text.
+func (f *Feeder) scan() []Token {
+	var toks []Token
+	for {
+		tok, more, ok := f.next()
+		if !ok || more {
+			return toks
+		}
+		toks = append(toks, tok)
+	}
+}
+
+// tokens is the body of the coroutine. It yields each token as it is
+// scanned and yields with more set when the input fed so far has been used.
+func (f *Feeder) tokens(yield func(Token, bool) bool) {
+	f.yield = yield
+	f.s.InitAt(f.pos, feedReader{f})
+	for {
+		tok := f.rules.Next(&f.s)
+		if tok.IsEndOfText() || !yield(tok, false) {
+			return
+		}
+	}
+}
+
+// feedReader is the source of the scanner used by a Feeder. A read with
+// nothing left to return suspends the coroutine until more is fed.
+type feedReader struct {
+	f *Feeder
+}
+
+func (r feedReader) Read(p []byte) (int, error) {
+	f := r.f
+	for len(f.head) == 0 && len(f.chunk) == 0 {
+		if f.closed || !f.yield(Token{}, true) {
+			return 0, io.EOF
+		}
+	}
+	if len(f.head) > 0 {
+		n := copy(p, f.head)
+		f.head = f.head[n:]
+		return n, nil
+	}
+	n := copy(p, f.chunk)
+	f.chunk = f.chunk[n:]
+	return n, nil
+}
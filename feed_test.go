@@ -0,0 +1,108 @@
+package scan
+
+import (
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func feedRules() RuleSet {
+	return NewRuleSet(
+		SkipSpaceRule,
+		NewCommentRule(Literal("/*"), Literal("*/")).WithKeep(true),
+		StrDoubleQuoteRule,
+		IntRule,
+		StandardIdentRule,
+		Literal("+", "+=", "(", ")"),
+	)
+}
+
+func TestFeed(t *testing.T) {
+	src := "abc += 123 /* é\n 世界 */ (\"x\\ny\") + héllo\n 42"
+	want := NewRunner(NewScannerFromString("feed", src), feedRules()).All()
+
+	for _, size := range []int{1, 2, 3, 7, len(src)} {
+		f := NewFeeder("feed", feedRules())
+		var have []Token
+		for data := []byte(src); len(data) > 0; {
+			n := min(size, len(data))
+			have = append(have, f.Feed(data[:n])...)
+			data = data[n:]
+		}
+		have = append(have, f.Close()...)
+		if !slices.EqualFunc(have, want, Token.Equal) {
+			t.Errorf("size %v:\n have: %v \n want: %v", size, have, want)
+		}
+	}
+}
+
+func TestFeedHold(t *testing.T) {
+	f := NewFeeder("", feedRules())
+	if toks := f.Feed([]byte("12")); len(toks) != 0 {
+		t.Fatalf("unexpected tokens: %v", toks)
+	}
+	if toks := f.Feed([]byte("3")); len(toks) != 0 {
+		t.Fatalf("unexpected tokens: %v", toks)
+	}
+	toks := f.Feed([]byte(" + "))
+	if len(toks) != 1 || toks[0].Val != "123" {
+		t.Fatalf("\n have: %v \n want: [123]", toks)
+	}
+	toks = f.Close()
+	if len(toks) != 1 || toks[0].Val != "+" {
+		t.Fatalf("\n have: %v \n want: [+]", toks)
+	}
+	if toks := f.Feed([]byte("4")); toks != nil {
+		t.Errorf("unexpected tokens after close: %v", toks)
+	}
+}
+
+func TestFeedIncomplete(t *testing.T) {
+	f := NewFeeder("", feedRules())
+	toks := append(f.Feed([]byte("ab \"cd")), f.Feed([]byte("\xe4\xb8"))...)
+	toks = append(toks, f.Close()...)
+	if len(toks) != 3 {
+		t.Fatalf("unexpected tokens: %v", toks)
+	}
+	if toks[1].Type != IllegalType {
+		t.Errorf("expected unterminated string: %v", toks[1])
+	}
+	last := toks[2]
+	if last.Lit != "\xe4\xb8" || !errors.Is(last.Errs[0], ErrIncompleteRune) {
+		t.Errorf("unexpected token: %v", last)
+	}
+	if last.Pos.Col != 7 || last.Pos.Offset != 6 {
+		t.Errorf("unexpected position: %v", last.Pos)
+	}
+}
+
+type countRule struct {
+	Rule
+	n *int
+}
+
+func (r countRule) Eval(s *Scanner) bool {
+	*r.n++
+	return r.Rule.Eval(s)
+}
+
+func TestFeedResume(t *testing.T) {
+	var evals int
+	rules := NewRuleSet(SkipSpaceRule, countRule{StrDoubleQuoteRule, &evals})
+	src := "\"" + strings.Repeat("x", 10000) + "\" "
+
+	f := NewFeeder("", rules)
+	var toks []Token
+	for i := range len(src) {
+		toks = append(toks, f.Feed([]byte{src[i]})...)
+	}
+	toks = append(toks, f.Close()...)
+	if len(toks) != 1 || toks[0].Val != strings.Repeat("x", 10000) {
+		t.Fatalf("unexpected tokens: %v", toks)
+	}
+	// The string is scanned once instead of once for each byte fed
+	if evals != 1 {
+		t.Errorf("rule evaluated %v times", evals)
+	}
+}
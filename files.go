@@ -0,0 +1,168 @@
+package scan
+
+import (
+	"context"
+	"errors"
+	"iter"
+	"os"
+	"runtime"
+	"sync"
+)
+
+// Pool holds scanners that can be reused. Scanners taken from the pool keep
+// the buffers they allocated while scanning earlier inputs. The zero value
+// is ready to use and a pool can be used by many goroutines at once.
+type Pool struct {
+	pool sync.Pool
+}
+
+// Get returns a scanner from the pool or a new scanner if the pool is
+// empty. The scanner must be initialized before it is used.
+func (p *Pool) Get() *Scanner {
+	if s, ok := p.pool.Get().(*Scanner); ok {
+		return s
+	}
+	return &Scanner{}
+}
+
+// Put returns a scanner to the pool. The scanner should not be used after
+// it has been returned.
+func (p *Pool) Put(s *Scanner) {
+	s.InitFromString("", "")
+	if s.reader != nil {
+		s.reader.Reset(nil)
+	}
+	s.ctx = nil
+	p.pool.Put(s)
+}
+
+// FileResult contains the tokens scanned from the file found at Path. Err
+// is set if the file could not be opened or if the context was done before
+// the scan of the file was complete.
+type FileResult struct {
+	Path string
+	Toks []Token
+	Err  error
+}
+
+var filePool Pool
+
+// ScanFiles scans the files in paths using up to workers goroutines and
+// returns an iterator over the results in the same order as paths. If
+// workers is less than one, the value of runtime.GOMAXPROCS is used. The
+// rules used to scan each file are returned by the rules function and the
+// runner for each file is created with opts.
+//
+// The rules function is called from the worker goroutines, at most once for
+// each path, and may be called concurrently. It must be safe for concurrent
+// use.
+//
+// Only a small number of results are scanned ahead of the result being
+// yielded. When the loop is stopped early or ctx is done, scanning stops.
+// Files that were already scanned are returned as usual and the remaining
+// results have the error from the context.
+func ScanFiles(ctx context.Context, paths []string, rules func(path string) RuleSet, workers int, opts ...RunnerOption) iter.Seq[FileResult] {
+	return func(yield func(FileResult) bool) {
+		if workers < 1 {
+			workers = runtime.GOMAXPROCS(0)
+		}
+		ctx, cancel := context.WithCancel(ctx)
+		var wg sync.WaitGroup
+		defer func() {
+			cancel()
+			wg.Wait()
+		}()
+
+		results := make([]chan FileResult, len(paths))
+		for i := range results {
+			results[i] = make(chan FileResult, 1)
+		}
+		jobs := make(chan int)
+		window := make(chan struct{}, workers*2)
+
+		// skip sets the results of the files, starting at i, that were not
+		// handed to a worker before ctx was done.
+		skip := func(i int) {
+			for ; i < len(paths); i++ {
+				results[i] <- FileResult{Path: paths[i], Err: ctx.Err()}
+			}
+		}
+
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			defer close(jobs)
+			for i := range paths {
+				select {
+				case window <- struct{}{}:
+				case <-ctx.Done():
+					skip(i)
+					return
+				}
+				select {
+				case jobs <- i:
+				case <-ctx.Done():
+					skip(i)
+					return
+				}
+			}
+		}()
+
+		for range workers {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for i := range jobs {
+					results[i] <- scanFile(ctx, paths[i], rules(paths[i]), opts)
+				}
+			}()
+		}
+
+		for i := range paths {
+			res := <-results[i]
+			// A file handed to a worker holds a place in the window. Files
+			// that were skipped do not.
+			select {
+			case <-window:
+			default:
+			}
+			if !yield(res) {
+				return
+			}
+		}
+	}
+}
+
+func scanFile(ctx context.Context, path string, rules RuleSet, opts []RunnerOption) FileResult {
+	res := FileResult{Path: path}
+	f, err := os.Open(path)
+	if err != nil {
+		res.Err = err
+		return res
+	}
+	defer f.Close()
+
+	s := filePool.Get()
+	defer filePool.Put(s)
+	s.Init(path, f)
+	opts = append([]RunnerOption{WithContext(ctx)}, opts...)
+	res.Toks = NewRunner(s, rules, opts...).All()
+	if err := ctx.Err(); err != nil && cutShort(res.Toks, err) {
+		res.Err = err
+	}
+	return res
+}
+
+// cutShort returns true if the last token has the error reported when the
+// runner was stopped by err.
+func cutShort(toks []Token, err error) bool {
+	if len(toks) == 0 {
+		return false
+	}
+	for _, e := range toks[len(toks)-1].Errs {
+		if errors.Is(e, err) {
+			return true
+		}
+	}
+	return false
+}
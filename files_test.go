@@ -0,0 +1,185 @@
+package scan
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func writeTestFiles(t *testing.T, n int) []string {
+	dir := t.TempDir()
+	var paths []string
+	for i := 0; i < n; i++ {
+		path := filepath.Join(dir, fmt.Sprintf("file%v.txt", i))
+		src := strings.Repeat(fmt.Sprintf("word%v ", i), i+1)
+		if err := os.WriteFile(path, []byte(src), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		paths = append(paths, path)
+	}
+	return paths
+}
+
+func TestScanFiles(t *testing.T) {
+	paths := writeTestFiles(t, 50)
+	missing := filepath.Join(filepath.Dir(paths[0]), "missing.txt")
+	paths = slices.Insert(paths, 10, missing)
+
+	rules := func(string) RuleSet { return NewRuleSet(SkipSpaceRule, WordRule) }
+	var i int
+	for res := range ScanFiles(context.Background(), paths, rules, 4) {
+		if res.Path != paths[i] {
+			t.Fatalf("\n have: %v \n want: %v", res.Path, paths[i])
+		}
+		if res.Path == missing {
+			if !errors.Is(res.Err, fs.ErrNotExist) {
+				t.Errorf("\n have: %v \n want: %v", res.Err, fs.ErrNotExist)
+			}
+			i++
+			continue
+		}
+		if res.Err != nil {
+			t.Fatal(res.Err)
+		}
+		data, _ := os.ReadFile(res.Path)
+		want := NewRunner(NewScannerFromBytes(res.Path, data), rules(res.Path)).All()
+		if !slices.EqualFunc(res.Toks, want, Token.Equal) {
+			t.Errorf("%v:\n have: %v \n want: %v", res.Path, res.Toks, want)
+		}
+		i++
+	}
+	if i != len(paths) {
+		t.Errorf("\n have: %v results \n want: %v", i, len(paths))
+	}
+}
+
+func TestScanFilesBreak(t *testing.T) {
+	paths := writeTestFiles(t, 100)
+	rules := func(string) RuleSet { return NewRuleSet(SkipSpaceRule, WordRule) }
+
+	var n int
+	for range ScanFiles(context.Background(), paths, rules, 4) {
+		n++
+		if n == 5 {
+			break
+		}
+	}
+	if n != 5 {
+		t.Errorf("\n have: %v \n want: 5", n)
+	}
+}
+
+func TestScanFilesCancel(t *testing.T) {
+	paths := writeTestFiles(t, 100)
+	rules := func(string) RuleSet { return NewRuleSet(SkipSpaceRule, WordRule) }
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	var n int
+	var errs int
+	for res := range ScanFiles(ctx, paths, rules, 4) {
+		n++
+		if n == 5 {
+			cancel()
+		}
+		if res.Err != nil {
+			if !errors.Is(res.Err, context.Canceled) {
+				t.Fatal(res.Err)
+			}
+			errs++
+		}
+	}
+	if n != len(paths) || errs == 0 {
+		t.Errorf("have %v results with %v errors", n, errs)
+	}
+}
+
+type poolKey struct{}
+
+func TestPool(t *testing.T) {
+	var p Pool
+	s := p.Get()
+	s.Init("a", strings.NewReader("abc def"))
+	if tok := NewRunner(s, NewRuleSet(WordRule), WithContext(context.Background())).This; tok.Val != "abc" {
+		t.Fatalf("\n have: %v \n want: abc", tok.Val)
+	}
+	s.Report(Error{Message: "stale"})
+	s.SetState(poolKey{}, true)
+	s.PushMode("m")
+	reader := s.reader
+	p.Put(s)
+
+	s = p.Get()
+	if s.reader != reader {
+		t.Skip("scanner was not returned by the pool")
+	}
+	if s.This != EndOfText || s.src != nil || s.text != "" {
+		t.Errorf("source was not reset")
+	}
+	if s.Pos != NewPos("") {
+		t.Errorf("\n have: %v \n want: %v", s.Pos, NewPos(""))
+	}
+	if len(s.Errs) != 0 {
+		t.Errorf("errors were not reset: %v", s.Errs)
+	}
+	if s.State(poolKey{}) != nil || s.Mode() != "" || s.ctx != nil {
+		t.Errorf("state was not reset")
+	}
+
+	s.Init("b", strings.NewReader("def"))
+	if s.reader != reader {
+		t.Errorf("reader was not reused")
+	}
+	tok := NewRunner(s, NewRuleSet(WordRule)).This
+	if tok.Val != "def" || tok.Pos != NewPos("b") {
+		t.Errorf("\n have: %v \n want: def at b:1:1", tok)
+	}
+}
+
+type cancelRule struct {
+	cancel context.CancelFunc
+}
+
+func (r cancelRule) Eval(s *Scanner) bool {
+	if s.This == '!' {
+		r.cancel()
+	}
+	return false
+}
+
+func TestScanFilesCancelComplete(t *testing.T) {
+	dir := t.TempDir()
+	paths := []string{filepath.Join(dir, "a.txt"), filepath.Join(dir, "b.txt")}
+	for _, path := range paths {
+		if err := os.WriteFile(path, []byte("a b !"), 0o644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	// The context is cancelled while scanning the last token of the first
+	// file. That file is still complete and is returned without an error.
+	rules := func(string) RuleSet {
+		return NewRuleSet(SkipSpaceRule, cancelRule{cancel}, WordRule, Literal("!"))
+	}
+
+	var results []FileResult
+	for res := range ScanFiles(ctx, paths, rules, 1) {
+		results = append(results, res)
+	}
+	if len(results) != 2 {
+		t.Fatalf("have %v results", len(results))
+	}
+	if res := results[0]; res.Err != nil || len(res.Toks) != 3 {
+		t.Errorf("unexpected result: %v %v", res.Toks, res.Err)
+	}
+	if res := results[1]; !errors.Is(res.Err, context.Canceled) {
+		t.Errorf("\n have: %v \n want: %v", res.Err, context.Canceled)
+	}
+}
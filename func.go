@@ -61,18 +61,52 @@ func Word(s *Scanner) string {
 
 func UnexpectedRune() func(*Scanner) {
 	return func(s *Scanner) {
-		s.Illegal("unexpected %s", QuoteRune(s.This))
+		pos, ch := s.Pos, s.This
 		s.Keep()
+		s.illegalAt(pos, InvalidInput, "rune.unexpected", "unexpected %s", QuoteRune(ch))
 	}
 }
 
 func UnexpectedUntil(c Class) func(*Scanner) {
 	return func(s *Scanner) {
 		Until(s, c, s.Keep)
-		s.Illegal("unexpected %s", Quote(s.Lit.String()))
+		s.illegal(InvalidInput, "text.unexpected", "unexpected %s", Quote(s.Lit.String()))
 	}
 }
 
+// InsertSemicolons returns a post token function that handles newlines in
+// languages where they end statements, such as Go. A token with a type of
+// newline is changed to a ";" token when the token before it has one of
+// the types found in after. Otherwise, the newline is dropped but its
+// literal is kept so that it can be kept as trivia. The type of the last
+// token is kept in the scanner state.
+func InsertSemicolons(newline string, after ...string) func(*Scanner, Token) Token {
+	required := make(map[string]bool, len(after))
+	for _, t := range after {
+		required[t] = true
+	}
+	return func(s *Scanner, t Token) Token {
+		last, _ := s.State(lastTypeKey{}).(*string)
+		if last == nil {
+			last = new(string)
+			s.SetState(lastTypeKey{}, last)
+		}
+		if t.Type == newline {
+			if required[*last] {
+				t.Type = ";"
+				t.Val = ";"
+			} else {
+				t.Type = ""
+				t.Val = ""
+			}
+		}
+		*last = t.Type
+		return t
+	}
+}
+
+type lastTypeKey struct{}
+
 var escapeMap = map[rune]string{
 	'\a': "\\a",
 	'\b': "\\b",
@@ -0,0 +1,922 @@
+// Package gen generates the Go source of a lexer from a spec.
+//
+// The generated lexer does not depend on this module. It works directly on
+// a []byte and replaces the rule evaluation of a scan.RuleSet with code
+// specialized for each rule: rules are selected with a switch on the first
+// byte, literals are matched with nested switches, and classes are inlined
+// with fast paths for ASCII. The tokens returned are the same as those
+// returned by RuleSet.Next for a rule set built from the same spec.
+//
+// A spec can be loaded from a file with spec.Load or declared in Go as a
+// spec.Spec value. Specs that use modes, longest match, or regular
+// expressions are not supported.
+package gen
+
+import (
+	"bytes"
+	_ "embed"
+	"errors"
+	"fmt"
+	"go/format"
+	"io"
+	"slices"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+
+	"github.com/blackchip-org/scan"
+	"github.com/blackchip-org/scan/spec"
+)
+
+//go:embed runtime.go.tmpl
+var runtime string
+
+// ErrUnsupported is the cause of the error returned when a spec uses a
+// feature that cannot be generated.
+var ErrUnsupported = errors.New("not supported by the generator")
+
+// Generate writes the source of a lexer for sp to w. The source is in
+// package pkg.
+func Generate(w io.Writer, pkg string, sp *spec.Spec) error {
+	if _, err := sp.RuleSet(); err != nil {
+		return err
+	}
+	if sp.LongestMatch {
+		return fmt.Errorf("%w: longestMatch", ErrUnsupported)
+	}
+	if len(sp.Modes) > 0 {
+		return fmt.Errorf("%w: modes", ErrUnsupported)
+	}
+
+	g := &generator{
+		kinds:   make(map[string]string),
+		classes: make(map[string]string),
+		tries:   make(map[string]string),
+	}
+	g.kind(scan.EndOfTextType)
+	g.kind(scan.IllegalType)
+	if err := g.eval(sp.Rules); err != nil {
+		return err
+	}
+	g.post(sp.Semicolons)
+
+	var out bytes.Buffer
+	fmt.Fprintf(&out, "// Code generated by scan-gen. DO NOT EDIT.\n\n")
+	if sp.Name != "" {
+		fmt.Fprintf(&out, "// Package %v is a lexer for %v.\n", pkg, sp.Name)
+	}
+	fmt.Fprintf(&out, "package %v\n\n", pkg)
+	fmt.Fprintf(&out, "import (\n\"fmt\"\n\"strings\"\n\"unicode\"\n\"unicode/utf8\"\n)\n\n")
+	out.Write(g.kindDecls())
+	out.WriteString(runtime)
+	out.Write(g.b.Bytes())
+
+	src, err := format.Source(out.Bytes())
+	if err != nil {
+		return fmt.Errorf("unable to format generated source: %w", err)
+	}
+	_, err = w.Write(src)
+	return err
+}
+
+type generator struct {
+	b         bytes.Buffer
+	kindNames []string
+	kindTypes []string
+	kinds     map[string]string
+	classes   map[string]string
+	tries     map[string]string
+	funcs     int
+}
+
+func (g *generator) p(format string, args ...any) {
+	fmt.Fprintf(&g.b, format+"\n", args...)
+}
+
+func (g *generator) newFunc(prefix string) string {
+	name := fmt.Sprintf("%v%v", prefix, g.funcs)
+	g.funcs++
+	return name
+}
+
+// kind returns the name of the constant for the token type typ.
+func (g *generator) kind(typ string) string {
+	if name, ok := g.kinds[typ]; ok {
+		return name
+	}
+	name := kindName(typ)
+	for i := 2; slices.Contains(g.kindNames, name); i++ {
+		name = kindName(typ) + strconv.Itoa(i)
+	}
+	g.kinds[typ] = name
+	g.kindNames = append(g.kindNames, name)
+	g.kindTypes = append(g.kindTypes, typ)
+	return name
+}
+
+func (g *generator) kindDecls() []byte {
+	var b bytes.Buffer
+	fmt.Fprintf(&b, "// Kind is the type of a token.\ntype Kind int\n\n")
+	fmt.Fprintf(&b, "const (\nkindNone Kind = iota\n")
+	for _, name := range g.kindNames {
+		fmt.Fprintf(&b, "%v\n", name)
+	}
+	fmt.Fprintf(&b, ")\n\nvar kindTypes = [...]string{\nkindNone: \"\",\n")
+	for i, name := range g.kindNames {
+		fmt.Fprintf(&b, "%v: %v,\n", name, strconv.Quote(g.kindTypes[i]))
+	}
+	fmt.Fprintf(&b, "}\n\n")
+	fmt.Fprintf(&b, "// String returns the token type used by the rule set.\n")
+	fmt.Fprintf(&b, "func (k Kind) String() string {\nreturn kindTypes[k]\n}\n\n")
+	return b.Bytes()
+}
+
+var symbolNames = map[rune]string{
+	'!': "Not", '"': "Quote", '#': "Hash", '$': "Dollar", '%': "Percent",
+	'&': "Amp", '\'': "Apos", '(': "LParen", ')': "RParen", '*': "Star",
+	'+': "Plus", ',': "Comma", '-': "Minus", '.': "Dot", '/': "Slash",
+	':': "Colon", ';': "Semi", '<': "Lt", '=': "Eq", '>': "Gt",
+	'?': "Question", '@': "At", '[': "LBrack", '\\': "Backslash",
+	']': "RBrack", '^': "Caret", '_': "Under", '`': "Backtick",
+	'{': "LBrace", '|': "Pipe", '}': "RBrace", '~': "Tilde",
+	' ': "Space", '\t': "Tab", '\n': "Newline", '\r': "Return",
+}
+
+// kindName returns a constant name for typ. Types with letters or digits
+// use those as words, such as "end-of-text" for KindEndOfText. Otherwise,
+// each symbol is named, such as "+=" for KindPlusEq.
+func kindName(typ string) string {
+	isWord := func(ch rune) bool { return unicode.IsLetter(ch) || unicode.IsDigit(ch) }
+	var b strings.Builder
+	b.WriteString("Kind")
+	if strings.ContainsFunc(typ, isWord) {
+		upper := true
+		for _, ch := range typ {
+			if !isWord(ch) {
+				upper = true
+				continue
+			}
+			if upper {
+				ch = unicode.ToUpper(ch)
+				upper = false
+			}
+			b.WriteRune(ch)
+		}
+		return b.String()
+	}
+	for _, ch := range typ {
+		if name, ok := symbolNames[ch]; ok {
+			b.WriteString(name)
+		} else {
+			fmt.Fprintf(&b, "U%04X", ch)
+		}
+	}
+	return b.String()
+}
+
+// eval generates the function that selects the rules to evaluate using the
+// current rune. Only the rules that can start with the rune are tried.
+func (g *generator) eval(rules []spec.Rule) error {
+	var names []string
+	var starts []scan.Class
+	var others []string
+	for i, r := range rules {
+		name, err := g.rule(r, true)
+		if err != nil {
+			return fmt.Errorf("rules[%v]: %w", i, err)
+		}
+		rule, err := r.Build()
+		if err != nil {
+			return err
+		}
+		names = append(names, name)
+		starts = append(starts, scan.StartOf(rule))
+		if startsNonASCII(r) {
+			others = append(others, name)
+		}
+	}
+
+	var keys []string
+	cases := make(map[string][]string)
+	for ch := rune(0); ch < utf8.RuneSelf; ch++ {
+		var calls []string
+		for i, name := range names {
+			if starts[i] == nil || starts[i](ch) {
+				calls = append(calls, "l."+name+"()")
+			}
+		}
+		if len(calls) == 0 {
+			continue
+		}
+		key := strings.Join(calls, " || ")
+		if _, ok := cases[key]; !ok {
+			keys = append(keys, key)
+		}
+		cases[key] = append(cases[key], strconv.QuoteRune(ch))
+	}
+
+	g.p("func (l *Lexer) eval() bool {")
+	g.p("if l.this >= utf8.RuneSelf {")
+	g.p("return %v", calls(others))
+	g.p("}")
+	g.p("switch l.this {")
+	for _, key := range keys {
+		g.p("case %v:", strings.Join(cases[key], ", "))
+		g.p("return %v", key)
+	}
+	g.p("}")
+	g.p("return false")
+	g.p("}\n")
+	return nil
+}
+
+func calls(names []string) string {
+	if len(names) == 0 {
+		return "false"
+	}
+	var cs []string
+	for _, name := range names {
+		cs = append(cs, "l."+name+"()")
+	}
+	return strings.Join(cs, " || ")
+}
+
+// post generates the function called with each token matched by a rule.
+func (g *generator) post(semi *spec.Semicolons) {
+	if semi == nil {
+		g.p("func (l *Lexer) post(t Token) Token {\nreturn t\n}\n")
+		return
+	}
+	var after []string
+	for _, typ := range semi.After {
+		if name, ok := g.kinds[typ]; ok && !slices.Contains(after, name) {
+			after = append(after, name)
+		}
+	}
+	g.p("func (l *Lexer) post(t Token) Token {")
+	if newline, ok := g.kinds[semi.Newline]; ok {
+		g.p("if t.Kind == %v {", newline)
+		g.p("switch l.last {")
+		if len(after) > 0 {
+			g.p("case %v:", strings.Join(after, ", "))
+			g.p("t.Kind, t.Val = %v, \";\"", g.kind(";"))
+		}
+		g.p("default:")
+		g.p("t.Kind, t.Val = kindNone, \"\"")
+		g.p("}")
+		g.p("}")
+	}
+	g.p("l.last = t.Kind")
+	g.p("return t")
+	g.p("}\n")
+}
+
+// rule generates the function for r and returns its name. When top is
+// true, the rule is used to match a token. Otherwise, it is used within
+// another rule and does not set the type of the token unless asked to.
+func (g *generator) rule(r spec.Rule, top bool) (string, error) {
+	switch r.Rule {
+	case "class":
+		return g.classRule(r, top)
+	case "comment":
+		return g.commentRule(r), nil
+	case "ident":
+		return g.identRule(r, top), nil
+	case "literal":
+		return g.literalRule(r, top), nil
+	case "num":
+		return g.numRule(r)
+	case "str":
+		return g.strRule(r)
+	case "while":
+		return g.whileRule(r)
+	}
+	return "", fmt.Errorf("%w: %v rule", ErrUnsupported, r.Rule)
+}
+
+func (g *generator) classRule(r spec.Rule, top bool) (string, error) {
+	if top && r.Type == "" {
+		return "", fmt.Errorf("%w: class rule without a type", ErrUnsupported)
+	}
+	name := g.newFunc("rule")
+	class := g.class(r.Class)
+	g.p("func (l *Lexer) %v() bool {", name)
+	g.p("if !%v(l.this) {\nreturn false\n}", class)
+	g.p("l.keep()")
+	if r.Type != "" {
+		g.p("l.kind = %v", g.kind(r.Type))
+	}
+	g.p("return true")
+	g.p("}\n")
+	return name, nil
+}
+
+func (g *generator) commentRule(r spec.Rule) string {
+	name := g.newFunc("rule")
+	begin := g.trie([]string{r.Begin}, false)
+	end := g.trie([]string{r.End}, false)
+	keep := r.Keep != nil && *r.Keep
+	g.p("func (l *Lexer) %v() bool {", name)
+	g.p("n := l.%v()", begin)
+	g.p("if n == 0 {\nreturn false\n}")
+	g.p("l.skipTo(l.pos.Offset + n)")
+	if keep {
+		g.p("l.kind = %v", g.kind(scan.CommentType))
+	}
+	g.p("for l.this != eot {")
+	g.p("if n := l.%v(); n > 0 {", end)
+	g.p("l.skipTo(l.pos.Offset + n)")
+	g.p("return true")
+	g.p("}")
+	if keep {
+		g.p("l.keep()")
+	} else {
+		g.p("l.skip()")
+	}
+	g.p("}")
+	if !strings.HasPrefix(r.End, "\n") {
+		g.p(`l.illegal(true, "comment.unterminated", "comment not terminated")`)
+	}
+	g.p("return true")
+	g.p("}\n")
+	return name
+}
+
+func (g *generator) identRule(r spec.Rule, top bool) string {
+	name := g.newFunc("rule")
+	head := g.class(r.Head)
+	tail := g.class(r.Tail)
+	g.p("func (l *Lexer) %v() bool {", name)
+	g.p("if !%v(l.this) {\nreturn false\n}", head)
+	g.p("l.keep()")
+	g.p("for l.this != eot && %v(l.this) {\nl.keep()\n}", tail)
+	if len(r.Keywords) == 0 {
+		g.p("l.kind = %v", g.kind(scan.IdentType))
+	} else {
+		g.p("switch string(l.val.bytes(l.src)) {")
+		var keywords []string
+		for _, k := range r.Keywords {
+			if !slices.Contains(keywords, k) {
+				keywords = append(keywords, k)
+			}
+		}
+		if top {
+			for _, k := range keywords {
+				g.p("case %v:", strconv.Quote(k))
+				g.p("l.kind = %v", g.kind(k))
+			}
+		} else {
+			var qs []string
+			for _, k := range keywords {
+				qs = append(qs, strconv.Quote(k))
+			}
+			g.p("case %v:", strings.Join(qs, ", "))
+		}
+		g.p("default:")
+		g.p("l.kind = %v", g.kind(scan.IdentType))
+		g.p("}")
+	}
+	g.p("return true")
+	g.p("}\n")
+	return name
+}
+
+func (g *generator) literalRule(r spec.Rule, top bool) string {
+	name := g.newFunc("rule")
+	lit := g.trie(r.Values, top)
+	g.p("func (l *Lexer) %v() bool {", name)
+	if top {
+		g.p("n, k := l.%v()", lit)
+	} else {
+		g.p("n := l.%v()", lit)
+	}
+	g.p("if n == 0 {\nreturn false\n}")
+	g.p("l.keepTo(l.pos.Offset + n)")
+	if top {
+		g.p("l.kind = k")
+	}
+	g.p("return true")
+	g.p("}\n")
+	return name
+}
+
+func (g *generator) numRule(r spec.Rule) (string, error) {
+	var suffix []string
+	for i, s := range r.Suffix {
+		name, err := g.rule(s, false)
+		if err != nil {
+			return "", fmt.Errorf("suffix[%v]: %w", i, err)
+		}
+		suffix = append(suffix, name)
+	}
+
+	name := g.newFunc("rule")
+	intKind := g.kind(orDefault(r.IntType, scan.IntType))
+	realKind := ""
+	if r.DecSep != nil || r.Exp != nil {
+		realKind = g.kind(orDefault(r.RealType, scan.RealType))
+	}
+	digit := g.class(r.Digits)
+	leadingZero := r.LeadingZero == nil || *r.LeadingZero
+	emptyParts := r.EmptyParts == nil || *r.EmptyParts
+	opt := func(c *spec.Class) string {
+		if c == nil {
+			return ""
+		}
+		return g.class(c)
+	}
+	sign, digitSep, decSep, exp, expSign := opt(r.Sign), opt(r.DigitSep), opt(r.DecSep), opt(r.Exp), opt(r.ExpSign)
+	prefix := ""
+	if len(r.Prefix) > 0 {
+		prefix = g.trie(r.Prefix, false)
+	}
+
+	digits := name + "Digits"
+	g.p("func (l *Lexer) %v(seen bool) bool {", digits)
+	g.p("for l.this != eot {")
+	g.p("switch {")
+	g.p("case %v(l.this):", digit)
+	g.p("seen = true")
+	g.p("l.keep()")
+	if digitSep != "" {
+		g.p("case %v(l.this) && %v(l.peek(1)) && %v(l.prev):", digitSep, digit, digit)
+		g.p("l.skip()")
+	}
+	g.p("default:\nreturn seen")
+	g.p("}")
+	g.p("}")
+	g.p("return seen")
+	g.p("}\n")
+
+	g.p("func (l *Lexer) %v() bool {", name)
+	if sign != "" {
+		g.p("if %v(l.this) {\nl.keep()\n}", sign)
+	}
+	if prefix != "" {
+		g.p("n := l.%v()", prefix)
+		g.p("if n == 0 {\nl.undo()\nreturn false\n}")
+		g.p("l.keepTo(l.pos.Offset + n)")
+	}
+	if digitSep != "" && r.LeadingDigitSep {
+		g.p("if %v(l.this) {\nl.skip()\n}", digitSep)
+	}
+	g.p("l.kind = %v", intKind)
+	if !leadingZero {
+		cond := "l.this == '0'"
+		if decSep != "" {
+			cond += fmt.Sprintf(" && !%v(l.peek(1))", decSep)
+		}
+		if exp != "" {
+			cond += fmt.Sprintf(" && !%v(l.peek(1))", exp)
+		}
+		g.p("if %v {\nl.keep()\nreturn true\n}", cond)
+	}
+	g.p("seen := l.%v(false)", digits)
+	if decSep != "" {
+		g.p("if %v(l.this) {", decSep)
+		if !emptyParts {
+			g.p("if !seen {\nl.undo()\nreturn false\n}")
+			g.p("if !%v(l.peek(1)) {\nreturn true\n}", digit)
+		}
+		g.p("l.kind = %v", realKind)
+		g.p("l.keep()")
+		g.p("seen = l.%v(seen)", digits)
+		g.p("}")
+	}
+	g.p("if !seen {\nl.undo()\nreturn false\n}")
+	if exp != "" {
+		cond := fmt.Sprintf("%v(l.peek(1))", digit)
+		if expSign != "" {
+			cond += fmt.Sprintf(" || %v(l.peek(1)) && %v(l.peek(2))", expSign, digit)
+		}
+		g.p("if %v(l.this) && (%v) {", exp, cond)
+		g.p("l.kind = %v", realKind)
+		g.p("l.keep()")
+		if expSign != "" {
+			g.p("if %v(l.this) {\nl.keep()\n}", expSign)
+		}
+		g.p("l.%v(seen)", digits)
+		g.p("}")
+	}
+	if len(suffix) > 0 {
+		g.p("_ = %v", calls(suffix))
+	}
+	g.p("return true")
+	g.p("}\n")
+	return name, nil
+}
+
+func (g *generator) strRule(r spec.Rule) (string, error) {
+	esc, err := g.escapes(r.Escapes)
+	if err != nil {
+		return "", err
+	}
+	name := g.newFunc("rule")
+	begin, _ := utf8.DecodeRuneInString(r.Begin)
+	end, _ := utf8.DecodeRuneInString(r.End)
+	escape, _ := utf8.DecodeRuneInString(r.Escape)
+	if r.Escape == "" {
+		escape = 0
+	}
+	nesting := r.Nesting && begin != end
+	q := strconv.QuoteRune
+
+	g.p("func (l *Lexer) %v() bool {", name)
+	g.p("if l.this != %v {\nreturn false\n}", q(begin))
+	g.p("l.skip()")
+	g.p("l.kind = %v", g.kind(orDefault(r.Type, scan.StrType)))
+	if nesting {
+		g.p("level := 1")
+	}
+	if r.MaxLen > 0 {
+		g.p("length := 0")
+	}
+	g.p("for l.this != eot {")
+	if nesting {
+		g.p("if l.this == %v {\nlevel++\n}", q(begin))
+		g.p("if l.this == %v {", q(end))
+		g.p("level--")
+		g.p("if level == 0 {\nl.skip()\nreturn true\n}")
+		g.p("}")
+	} else {
+		g.p("if l.this == %v {\nl.skip()\nreturn true\n}", q(end))
+	}
+	if r.MaxLen > 0 {
+		g.p("length++")
+		g.p("if length > %v {", r.MaxLen)
+		g.p(`l.illegal(false, "str.too-long", fmt.Sprintf("too many characters (%%v)", length))`)
+		g.p("l.skipPast(%v)", q(end))
+		g.p("return true")
+		g.p("}")
+	}
+	g.p("switch {")
+	g.p("case l.this == '\\n':")
+	if r.Multiline {
+		g.p("l.keep()")
+	} else {
+		if !r.OptionalTerminator {
+			g.p(`l.illegal(false, "str.unterminated", "not terminated")`)
+		}
+		g.p("return true")
+	}
+	g.p("case l.this == %v:", q(escape))
+	g.p("l.skip()")
+	g.p("if l.this == %v || l.this == %v {", q(end), q(escape))
+	g.p("l.keep()")
+	g.p("} else if pos, ch := l.pos, l.this; !l.%v() {", esc)
+	g.p("l.keep()")
+	g.p(`l.illegalAt(pos, false, "str.invalid-escape", fmt.Sprintf("invalid escape sequence: '%%c%%c'", %v, ch))`, q(escape))
+	g.p("l.skipPast(%v)", q(end))
+	g.p("return true")
+	g.p("} else if len(l.errs) > 0 {")
+	g.p("l.skipPast(%v)", q(end))
+	g.p("return true")
+	g.p("}")
+	g.p("default:")
+	g.p("l.keep()")
+	g.p("}")
+	g.p("}")
+	if !r.OptionalTerminator {
+		g.p(`l.illegal(true, "str.unterminated", "not terminated")`)
+	}
+	g.p("return true")
+	g.p("}\n")
+	return name, nil
+}
+
+func (g *generator) whileRule(r spec.Rule) (string, error) {
+	keep := r.Keep == nil || *r.Keep
+	if keep && r.Type == "" {
+		return "", fmt.Errorf("%w: while rule without a type", ErrUnsupported)
+	}
+	name := g.newFunc("rule")
+	class := g.class(r.Class)
+	g.p("func (l *Lexer) %v() bool {", name)
+	g.p("if !%v(l.this) {\nreturn false\n}", class)
+	if keep {
+		g.p("l.kind = %v", g.kind(r.Type))
+		g.p("for l.this != eot && %v(l.this) {\nl.keep()\n}", class)
+	} else {
+		g.p("for l.this != eot && %v(l.this) {\nl.discard()\n}", class)
+	}
+	g.p("return true")
+	g.p("}\n")
+	return name, nil
+}
+
+// escapes generates the function that evaluates the escape rules of a
+// string and returns its name.
+func (g *generator) escapes(rules []spec.Rule) (string, error) {
+	var names []string
+	for i, r := range rules {
+		var name string
+		switch r.Rule {
+		case "charEnc":
+			name = g.charEnc(r)
+		case "hexEnc":
+			name = g.hexEnc(r)
+		case "octEnc":
+			name = g.octEnc()
+		default:
+			return "", fmt.Errorf("escapes[%v]: %w: %v rule", i, ErrUnsupported, r.Rule)
+		}
+		names = append(names, name)
+	}
+	name := g.newFunc("esc")
+	g.p("func (l *Lexer) %v() bool {\nreturn %v\n}\n", name, calls(names))
+	return name, nil
+}
+
+func (g *generator) charEnc(r spec.Rule) string {
+	name := g.newFunc("esc")
+	var from []string
+	for k := range r.Map {
+		from = append(from, k)
+	}
+	sort.Strings(from)
+	g.p("func (l *Lexer) %v() bool {", name)
+	g.p("var to rune")
+	g.p("switch l.this {")
+	for _, k := range from {
+		f, _ := utf8.DecodeRuneInString(k)
+		t, _ := utf8.DecodeRuneInString(r.Map[k])
+		g.p("case %v:\nto = %v", strconv.QuoteRune(f), strconv.QuoteRune(t))
+	}
+	g.p("default:\nreturn false")
+	g.p("}")
+	g.p("l.writeRune(to)")
+	g.p("l.skip()")
+	g.p("return true")
+	g.p("}\n")
+	return name
+}
+
+func (g *generator) hexEnc(r spec.Rule) string {
+	name := g.newFunc("esc")
+	flag, _ := utf8.DecodeRuneInString(r.Flag)
+	g.p("func (l *Lexer) %v() bool {", name)
+	g.p("if l.this != %v {\nreturn false\n}", strconv.QuoteRune(flag))
+	g.p("l.skip()")
+	g.p("start := l.pos")
+	g.p("var digits [%v]rune", r.Width)
+	g.p("var val uint32")
+	g.p("for i := range digits {")
+	g.p("ch := l.peek(i)")
+	g.p("var d rune")
+	g.p("switch {")
+	g.p("case '0' <= ch && ch <= '9':\nd = ch - '0'")
+	g.p("case 'a' <= ch && ch <= 'f':\nd = ch - 'a' + 10")
+	g.p("case 'A' <= ch && ch <= 'F':\nd = ch - 'A' + 10")
+	g.p("default:")
+	g.p("l.keepN(i)")
+	g.p(`l.illegalAt(start, false, "esc.invalid", "invalid encoding: "+quote(string(digits[:i])))`)
+	g.p("return true")
+	g.p("}")
+	g.p("digits[i] = ch")
+	g.p("val = val<<4 | uint32(d)")
+	g.p("}")
+	if r.AsByte {
+		g.p("l.writeByte(byte(val))")
+	} else {
+		g.p("if !utf8.ValidRune(rune(val)) {")
+		g.p("l.keepN(len(digits))")
+		g.p(`l.illegalAt(start, false, "esc.invalid", "invalid encoding: "+quote(string(digits[:])))`)
+		g.p("return true")
+		g.p("}")
+		g.p("l.writeRune(rune(val))")
+	}
+	g.p("l.skipN(len(digits))")
+	g.p("return true")
+	g.p("}\n")
+	return name
+}
+
+func (g *generator) octEnc() string {
+	name := g.newFunc("esc")
+	g.p("func (l *Lexer) %v() bool {", name)
+	g.p("if l.this < '0' || l.this > '7' {\nreturn false\n}")
+	g.p("start := l.pos")
+	g.p("var digits [3]rune")
+	g.p("val := 0")
+	g.p("for i := range digits {")
+	g.p("ch := l.peek(i)")
+	g.p("if ch < '0' || ch > '7' {")
+	g.p("l.keepN(i)")
+	g.p(`l.illegalAt(start, false, "esc.invalid", "invalid encoding: "+quote(string(digits[:i])))`)
+	g.p("return true")
+	g.p("}")
+	g.p("digits[i] = ch")
+	g.p("val = val<<3 | int(ch-'0')")
+	g.p("}")
+	g.p("if val > 0xff {")
+	g.p("l.keepN(len(digits))")
+	g.p(`l.illegalAt(start, false, "esc.invalid", "invalid encoding: "+quote(string(digits[:])))`)
+	g.p("return true")
+	g.p("}")
+	g.p("l.skipN(len(digits))")
+	g.p("l.writeRune(rune(val))")
+	g.p("return true")
+	g.p("}\n")
+	return name
+}
+
+// trie generates a function that returns the length in bytes of the
+// longest of lits found at the current position, or zero if none are
+// found. When kinds is true, the function also returns the kind of the
+// literal found.
+func (g *generator) trie(lits []string, kinds bool) string {
+	key := fmt.Sprint(kinds, lits)
+	if name, ok := g.tries[key]; ok {
+		return name
+	}
+	type node struct {
+		children map[byte]*node
+		lit      string
+	}
+	root := &node{children: make(map[byte]*node)}
+	for _, lit := range lits {
+		n := root
+		for i := 0; i < len(lit); i++ {
+			child, ok := n.children[lit[i]]
+			if !ok {
+				child = &node{children: make(map[byte]*node)}
+				n.children[lit[i]] = child
+			}
+			n = child
+		}
+		n.lit = lit
+	}
+
+	name := g.newFunc("lit")
+	g.tries[key] = name
+	if kinds {
+		g.p("func (l *Lexer) %v() (int, Kind) {", name)
+		g.p("n, k := 0, kindNone")
+	} else {
+		g.p("func (l *Lexer) %v() int {", name)
+		g.p("n := 0")
+	}
+	g.p("src := l.src[l.pos.Offset:]")
+	var walk func(*node, int)
+	walk = func(n *node, depth int) {
+		if len(n.children) == 0 {
+			return
+		}
+		var bs []byte
+		for b := range n.children {
+			bs = append(bs, b)
+		}
+		slices.Sort(bs)
+		g.p("if len(src) > %v {", depth)
+		g.p("switch src[%v] {", depth)
+		for _, b := range bs {
+			child := n.children[b]
+			if b < utf8.RuneSelf {
+				g.p("case %v:", strconv.QuoteRune(rune(b)))
+			} else {
+				g.p("case 0x%02x:", b)
+			}
+			if child.lit != "" {
+				if kinds {
+					g.p("n, k = %v, %v", depth+1, g.kind(child.lit))
+				} else {
+					g.p("n = %v", depth+1)
+				}
+			}
+			walk(child, depth+1)
+		}
+		g.p("}")
+		g.p("}")
+	}
+	walk(root, 0)
+	if kinds {
+		g.p("return n, k")
+	} else {
+		g.p("return n")
+	}
+	g.p("}\n")
+	return name
+}
+
+// class generates a function for class c and returns its name.
+func (g *generator) class(c *spec.Class) string {
+	expr := classExpr(c)
+	if name, ok := g.classes[expr]; ok {
+		return name
+	}
+	name := g.newFunc("class")
+	g.classes[expr] = name
+	g.p("func %v(c rune) bool {\nreturn %v\n}\n", name, expr)
+	return name
+}
+
+var classExprs = map[string]string{
+	"IsAny":              "c >= 0",
+	"IsCurrency":         "unicode.Is(unicode.Sc, c)",
+	"IsDigit":            "'0' <= c && c <= '9' || c >= utf8.RuneSelf && unicode.IsDigit(c)",
+	"IsDigit01":          "c == '0' || c == '1'",
+	"IsDigit07":          "'0' <= c && c <= '7'",
+	"IsDigit09":          "'0' <= c && c <= '9'",
+	"IsDigit0F":          "'0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'",
+	"IsLetter":           "'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c >= utf8.RuneSelf && unicode.IsLetter(c)",
+	"IsLetterAZ":         "'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z'",
+	"IsLetterUnder":      "'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_' || c >= utf8.RuneSelf && unicode.IsLetter(c)",
+	"IsLetterDigitUnder": "'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_' || '0' <= c && c <= '9' || c >= utf8.RuneSelf && (unicode.IsLetter(c) || unicode.IsDigit(c))",
+	"IsNone":             "false",
+	"IsPrintable":        "unicode.IsPrint(c)",
+	"IsRune8":            "0 <= c && c <= 0xff",
+	"IsRune16":           "0 <= c && c <= 0xffff",
+	"IsSign":             "c == '+' || c == '-'",
+	"IsSpace":            "c == ' ' || '\\t' <= c && c <= '\\r' || c >= utf8.RuneSelf && unicode.IsSpace(c)",
+}
+
+// classExpr returns a Go expression that is true when the rune c is a
+// member of the class.
+func classExpr(c *spec.Class) string {
+	switch {
+	case c.Name != "":
+		return classExprs[c.Name]
+	case c.Runes != "":
+		var es []string
+		for _, ch := range c.Runes {
+			es = append(es, "c == "+strconv.QuoteRune(ch))
+		}
+		return strings.Join(es, " || ")
+	case c.Range != "":
+		rs := []rune(c.Range)
+		return fmt.Sprintf("%v <= c && c <= %v", strconv.QuoteRune(rs[0]), strconv.QuoteRune(rs[1]))
+	case len(c.Or) > 0:
+		var es []string
+		for i := range c.Or {
+			es = append(es, "("+classExpr(&c.Or[i])+")")
+		}
+		return strings.Join(es, " || ")
+	case c.Not != nil:
+		return "!(" + classExpr(c.Not) + ")"
+	}
+	return "false"
+}
+
+// startsNonASCII returns true if r might match when the current rune is
+// not ASCII. It is only false when that is known to be impossible.
+func startsNonASCII(r spec.Rule) bool {
+	first := func(s string) bool {
+		ch, _ := utf8.DecodeRuneInString(s)
+		return ch >= utf8.RuneSelf
+	}
+	switch r.Rule {
+	case "class", "while":
+		return classNonASCII(r.Class)
+	case "comment", "str":
+		return first(r.Begin)
+	case "ident":
+		return classNonASCII(r.Head)
+	case "literal":
+		return slices.ContainsFunc(r.Values, first)
+	case "num":
+		if classNonASCII(r.Sign) {
+			return true
+		}
+		if len(r.Prefix) > 0 {
+			return slices.ContainsFunc(r.Prefix, first)
+		}
+		return classNonASCII(r.Digits) || classNonASCII(r.DecSep) ||
+			(r.LeadingDigitSep && classNonASCII(r.DigitSep))
+	}
+	return true
+}
+
+func classNonASCII(c *spec.Class) bool {
+	switch {
+	case c == nil:
+		return false
+	case c.Name != "":
+		switch c.Name {
+		case "IsDigit01", "IsDigit07", "IsDigit09", "IsDigit0F", "IsLetterAZ", "IsNone", "IsSign":
+			return false
+		}
+		return true
+	case c.Runes != "":
+		return strings.ContainsFunc(c.Runes, func(ch rune) bool { return ch >= utf8.RuneSelf })
+	case c.Range != "":
+		rs := []rune(c.Range)
+		return rs[1] >= utf8.RuneSelf
+	case len(c.Or) > 0:
+		for i := range c.Or {
+			if classNonASCII(&c.Or[i]) {
+				return true
+			}
+		}
+		return false
+	}
+	return true
+}
+
+func orDefault(s string, def string) string {
+	if s == "" {
+		return def
+	}
+	return s
+}
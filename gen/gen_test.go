@@ -0,0 +1,288 @@
+package gen
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/blackchip-org/scan"
+	"github.com/blackchip-org/scan/gen/internal/golex"
+	"github.com/blackchip-org/scan/gen/internal/jsonlex"
+	"github.com/blackchip-org/scan/gen/internal/numlex"
+	"github.com/blackchip-org/scan/scango"
+	"github.com/blackchip-org/scan/scanjson"
+	"github.com/blackchip-org/scan/spec"
+)
+
+//go:generate go run ../cmd/scan-gen -spec ../spec/testdata/go.json -pkg golex -o internal/golex/lexer.go
+//go:generate go run ../cmd/scan-gen -spec ../spec/testdata/json.json -pkg jsonlex -o internal/jsonlex/lexer.go
+//go:generate go run ../cmd/scan-gen -spec ../spec/testdata/num.json -pkg numlex -o internal/numlex/lexer.go
+
+func ruleSetTokens(rs scan.RuleSet, name string, src []byte) []scan.Token {
+	s := scan.NewScannerFromBytes(name, src)
+	var toks []scan.Token
+	for {
+		t := rs.Next(s)
+		toks = append(toks, t)
+		if t.IsEndOfText() {
+			return toks
+		}
+	}
+}
+
+func errKind(incomplete bool) scan.ErrorKind {
+	if incomplete {
+		return scan.IncompleteInput
+	}
+	return scan.InvalidInput
+}
+
+// lexPos, lexError and lexToken match the types declared in each
+// generated lexer package.
+type lexPos interface {
+	~struct {
+		Name   string
+		Line   int
+		Col    int
+		Offset int
+	}
+}
+
+type lexError[P lexPos] interface {
+	~struct {
+		Pos        P
+		End        P
+		Message    string
+		Code       string
+		Incomplete bool
+	}
+}
+
+type lexToken[K fmt.Stringer, P lexPos, E lexError[P]] interface {
+	~struct {
+		Kind K
+		Val  string
+		Lit  string
+		Pos  P
+		End  P
+		Errs []E
+	}
+}
+
+// lexTokens converts the tokens returned by next, the Next method of a
+// generated lexer, until a token of kind eot is found.
+func lexTokens[T lexToken[K, P, E], K interface {
+	comparable
+	fmt.Stringer
+}, P lexPos, E lexError[P]](next func() T, eot K) []scan.Token {
+	var toks []scan.Token
+	for {
+		t := struct {
+			Kind K
+			Val  string
+			Lit  string
+			Pos  P
+			End  P
+			Errs []E
+		}(next())
+		tok := scan.Token{
+			Type: t.Kind.String(),
+			Val:  t.Val,
+			Lit:  t.Lit,
+			Pos:  scan.Pos(t.Pos),
+			End:  scan.Pos(t.End),
+		}
+		for _, e := range t.Errs {
+			e := struct {
+				Pos        P
+				End        P
+				Message    string
+				Code       string
+				Incomplete bool
+			}(e)
+			tok.Errs = append(tok.Errs, scan.Error{
+				Pos:     scan.Pos(e.Pos),
+				End:     scan.Pos(e.End),
+				Message: e.Message,
+				Code:    e.Code,
+				Kind:    errKind(e.Incomplete),
+			})
+		}
+		toks = append(toks, tok)
+		if t.Kind == eot {
+			return toks
+		}
+	}
+}
+
+func compare(t *testing.T, name string, have []scan.Token, want []scan.Token) {
+	t.Helper()
+	for i := range min(len(have), len(want)) {
+		if !have[i].Equal(want[i]) {
+			t.Fatalf("%v: token %v\n have: %v \n want: %v", name, i, have[i], want[i])
+		}
+	}
+	if len(have) != len(want) {
+		t.Fatalf("%v: have %v tokens, want %v", name, len(have), len(want))
+	}
+}
+
+func corpus(t *testing.T, patterns ...string) map[string][]byte {
+	t.Helper()
+	files := make(map[string][]byte)
+	for _, pattern := range patterns {
+		paths, err := filepath.Glob(pattern)
+		if err != nil {
+			t.Fatal(err)
+		}
+		for _, path := range paths {
+			src, err := os.ReadFile(path)
+			if err != nil {
+				t.Fatal(err)
+			}
+			files[path] = src
+		}
+	}
+	if len(files) == 0 {
+		t.Fatal("empty corpus")
+	}
+	return files
+}
+
+var goCases = []string{
+	"",
+	"x := 0x1p-2i + 0b_1 + 0o17 + 1_000.5e+3i + .5 + 1. + 0X_F",
+	"'a' '\\x80' '\\u00e9' '\\U0001F600' '\\377' '\\400' '\\q' 'ab' '\\xZ'",
+	"\"\\x80\\u00e9\" \"\\uD800\" \"\\U00110000\" \"abc\n\"abc",
+	"`raw\nstring` `unterminated",
+	"/* comment */ x // line\ny /* unterminated",
+	"return\nx++\n}\n)\nfoo // c\nbar \n1\n",
+	"a &^= b <<= c ... d",
+	"é := ü + 中文 # $ @ \x80 \xff",
+	"1__2 1_ 0b2 0x 0o",
+}
+
+var jsonCases = []string{
+	"",
+	`[-0, 01, 1., .5, -1e+5, 1E-2, -, 0.0]`,
+	`{"a": "\u00e9\n\t\"\\\/", "b": "\x", "c": "\u12"}`,
+	`[true, false, null, tru, nul]`,
+	"\"abc\n\" \"abc",
+	"[1, \xff, é]",
+}
+
+var numCases = []string{
+	"0123 0 00 0.5 01.5e3 007",
+	"0. .5 0e 1x",
+}
+
+func TestGoLexer(t *testing.T) {
+	rs := scango.NewContext().RuleSet
+	files := corpus(t, "../*.go", "../scango/*.go", "../gen/*.go", "../gen/internal/*/*.go")
+	for i, src := range goCases {
+		files["case"+string(rune('a'+i))] = []byte(src)
+	}
+	for name, src := range files {
+		compare(t, name, lexTokens(golex.New(name, src).Next, golex.KindEndOfText), ruleSetTokens(rs, name, src))
+	}
+}
+
+func TestJSONLexer(t *testing.T) {
+	rs := scanjson.NewContext().RuleSet
+	files := corpus(t, "../scanjson/*.json", "../spec/testdata/*.json")
+	for i, src := range jsonCases {
+		files["case"+string(rune('a'+i))] = []byte(src)
+	}
+	for name, src := range files {
+		compare(t, name, lexTokens(jsonlex.New(name, src).Next, jsonlex.KindEndOfText), ruleSetTokens(rs, name, src))
+	}
+}
+
+func TestNumLexer(t *testing.T) {
+	sp, err := spec.Load("../spec/testdata/num.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, err := sp.RuleSet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	for i, src := range numCases {
+		name := "case" + string(rune('a'+i))
+		compare(t, name, lexTokens(numlex.New(name, []byte(src)).Next, numlex.KindEndOfText), ruleSetTokens(rs, name, []byte(src)))
+	}
+}
+
+func TestGenerated(t *testing.T) {
+	tests := []struct {
+		spec string
+		pkg  string
+		out  string
+	}{
+		{"../spec/testdata/go.json", "golex", "internal/golex/lexer.go"},
+		{"../spec/testdata/json.json", "jsonlex", "internal/jsonlex/lexer.go"},
+		{"../spec/testdata/num.json", "numlex", "internal/numlex/lexer.go"},
+	}
+	for _, test := range tests {
+		sp, err := spec.Load(test.spec)
+		if err != nil {
+			t.Fatal(err)
+		}
+		var have bytes.Buffer
+		if err := Generate(&have, test.pkg, sp); err != nil {
+			t.Fatal(err)
+		}
+		want, err := os.ReadFile(test.out)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if !bytes.Equal(have.Bytes(), want) {
+			t.Errorf("%v is out of date, run go generate", test.out)
+		}
+	}
+}
+
+func TestUnsupported(t *testing.T) {
+	tests := []string{
+		`{"rules": [{"rule": "regex", "pattern": "a+", "type": "a"}]}`,
+		`{"rules": [{"rule": "while", "class": "IsSpace"}]}`,
+		`{"rules": [{"rule": "class", "class": "IsSpace"}]}`,
+		`{"rules": [], "longestMatch": true}`,
+		`{"rules": [], "modes": {"m": []}}`,
+	}
+	for _, test := range tests {
+		sp, err := spec.Parse([]byte(test))
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := Generate(&bytes.Buffer{}, "lexer", sp); !errors.Is(err, ErrUnsupported) {
+			t.Errorf("%v\n have: %v \n want: %v", test, err, ErrUnsupported)
+		}
+	}
+}
+
+func BenchmarkRuleSet(b *testing.B) {
+	src, err := os.ReadFile("../scango/scango.go")
+	if err != nil {
+		b.Fatal(err)
+	}
+	rs := scango.NewContext().RuleSet
+	b.SetBytes(int64(len(src)))
+	for range b.N {
+		ruleSetTokens(rs, "", src)
+	}
+}
+
+func BenchmarkGenerated(b *testing.B) {
+	src, err := os.ReadFile("../scango/scango.go")
+	if err != nil {
+		b.Fatal(err)
+	}
+	b.SetBytes(int64(len(src)))
+	for range b.N {
+		golex.New("", src).All()
+	}
+}
@@ -0,0 +1,1690 @@
+// Code generated by scan-gen. DO NOT EDIT.
+
+// Package golex is a lexer for go.
+package golex
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind is the type of a token.
+type Kind int
+
+const (
+	kindNone Kind = iota
+	KindEndOfText
+	KindIllegal
+	KindRune
+	KindImag
+	KindInt
+	KindFloat
+	KindString
+	KindBreak
+	KindCase
+	KindChan
+	KindConst
+	KindContinue
+	KindDefault
+	KindDefer
+	KindElse
+	KindFallthrough
+	KindFor
+	KindFunc
+	KindGo
+	KindGoto
+	KindIf
+	KindImport
+	KindInterface
+	KindMap
+	KindPackage
+	KindRange
+	KindReturn
+	KindSelect
+	KindStruct
+	KindSwitch
+	KindType
+	KindVar
+	KindIdent
+	KindNewline
+	KindNot
+	KindNotEq
+	KindPercent
+	KindPercentEq
+	KindAmp
+	KindAmpAmp
+	KindAmpEq
+	KindAmpCaret
+	KindAmpCaretEq
+	KindLParen
+	KindRParen
+	KindStar
+	KindStarEq
+	KindPlus
+	KindPlusPlus
+	KindPlusEq
+	KindComma
+	KindMinus
+	KindMinusMinus
+	KindMinusEq
+	KindDot
+	KindDotDotDot
+	KindSlash
+	KindSlashEq
+	KindColon
+	KindColonEq
+	KindSemi
+	KindLt
+	KindLtMinus
+	KindLtLt
+	KindLtLtEq
+	KindLtEq
+	KindEq
+	KindEqEq
+	KindGt
+	KindGtEq
+	KindGtGt
+	KindGtGtEq
+	KindLBrack
+	KindRBrack
+	KindCaret
+	KindCaretEq
+	KindLBrace
+	KindPipe
+	KindPipeEq
+	KindPipePipe
+	KindRBrace
+	KindTilde
+)
+
+var kindTypes = [...]string{
+	kindNone:        "",
+	KindEndOfText:   "end-of-text",
+	KindIllegal:     "illegal",
+	KindRune:        "rune",
+	KindImag:        "imag",
+	KindInt:         "int",
+	KindFloat:       "float",
+	KindString:      "string",
+	KindBreak:       "break",
+	KindCase:        "case",
+	KindChan:        "chan",
+	KindConst:       "const",
+	KindContinue:    "continue",
+	KindDefault:     "default",
+	KindDefer:       "defer",
+	KindElse:        "else",
+	KindFallthrough: "fallthrough",
+	KindFor:         "for",
+	KindFunc:        "func",
+	KindGo:          "go",
+	KindGoto:        "goto",
+	KindIf:          "if",
+	KindImport:      "import",
+	KindInterface:   "interface",
+	KindMap:         "map",
+	KindPackage:     "package",
+	KindRange:       "range",
+	KindReturn:      "return",
+	KindSelect:      "select",
+	KindStruct:      "struct",
+	KindSwitch:      "switch",
+	KindType:        "type",
+	KindVar:         "var",
+	KindIdent:       "ident",
+	KindNewline:     "\n",
+	KindNot:         "!",
+	KindNotEq:       "!=",
+	KindPercent:     "%",
+	KindPercentEq:   "%=",
+	KindAmp:         "&",
+	KindAmpAmp:      "&&",
+	KindAmpEq:       "&=",
+	KindAmpCaret:    "&^",
+	KindAmpCaretEq:  "&^=",
+	KindLParen:      "(",
+	KindRParen:      ")",
+	KindStar:        "*",
+	KindStarEq:      "*=",
+	KindPlus:        "+",
+	KindPlusPlus:    "++",
+	KindPlusEq:      "+=",
+	KindComma:       ",",
+	KindMinus:       "-",
+	KindMinusMinus:  "--",
+	KindMinusEq:     "-=",
+	KindDot:         ".",
+	KindDotDotDot:   "...",
+	KindSlash:       "/",
+	KindSlashEq:     "/=",
+	KindColon:       ":",
+	KindColonEq:     ":=",
+	KindSemi:        ";",
+	KindLt:          "<",
+	KindLtMinus:     "<-",
+	KindLtLt:        "<<",
+	KindLtLtEq:      "<<=",
+	KindLtEq:        "<=",
+	KindEq:          "=",
+	KindEqEq:        "==",
+	KindGt:          ">",
+	KindGtEq:        ">=",
+	KindGtGt:        ">>",
+	KindGtGtEq:      ">>=",
+	KindLBrack:      "[",
+	KindRBrack:      "]",
+	KindCaret:       "^",
+	KindCaretEq:     "^=",
+	KindLBrace:      "{",
+	KindPipe:        "|",
+	KindPipeEq:      "|=",
+	KindPipePipe:    "||",
+	KindRBrace:      "}",
+	KindTilde:       "~",
+}
+
+// String returns the token type used by the rule set.
+func (k Kind) String() string {
+	return kindTypes[k]
+}
+
+const eot = rune(-1)
+
+// Pos is a position in the source. Offset is the number of bytes from the
+// start of the source.
+type Pos struct {
+	Name   string
+	Line   int
+	Col    int
+	Offset int
+}
+
+func (p Pos) String() string {
+	if p.Name != "" {
+		return fmt.Sprintf("%v:%v:%v", p.Name, p.Line, p.Col)
+	}
+	return fmt.Sprintf("%v:%v", p.Line, p.Col)
+}
+
+// Error is a problem found in the source. End is the position just after
+// the text with the problem, if known. Incomplete is true when the source
+// ended before the token was complete.
+type Error struct {
+	Pos        Pos
+	End        Pos
+	Message    string
+	Code       string
+	Incomplete bool
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%v: error: %v", e.Pos, e.Message)
+}
+
+// Token is a value returned by the lexer. Pos is the position of the first
+// rune in the token and End is the position just after the last rune.
+type Token struct {
+	Kind Kind
+	Val  string
+	Lit  string
+	Pos  Pos
+	End  Pos
+	Errs []Error
+}
+
+// buffer collects the bytes of a token value or literal. Bytes added in the
+// same order that they appear in the source are tracked as a slice of the
+// source until the contents diverge.
+type buffer struct {
+	start  int
+	end    int
+	copied bool
+	b      []byte
+}
+
+func (b *buffer) add(src []byte, ch rune, off int, width int) {
+	if !b.copied && !(ch == utf8.RuneError && width == 1) {
+		switch {
+		case b.start == b.end:
+			b.start, b.end = off, off+width
+			return
+		case b.end == off:
+			b.end += width
+			return
+		}
+	}
+	b.spill(src)
+	b.b = utf8.AppendRune(b.b, ch)
+}
+
+func (b *buffer) spill(src []byte) {
+	if b.copied {
+		return
+	}
+	b.copied = true
+	b.b = append(b.b[:0], src[b.start:b.end]...)
+}
+
+func (b *buffer) bytes(src []byte) []byte {
+	if !b.copied {
+		return src[b.start:b.end]
+	}
+	return b.b
+}
+
+func (b *buffer) reset() {
+	b.start, b.end = 0, 0
+	b.copied = false
+	b.b = b.b[:0]
+}
+
+// Lexer splits a source into tokens.
+type Lexer struct {
+	src  []byte
+	this rune
+	size int
+	prev rune
+	pos  Pos
+	tok  Pos
+	val  buffer
+	lit  buffer
+	kind Kind
+	errs []Error
+	last Kind
+}
+
+// New returns a lexer for src. The name is used in the positions of the
+// tokens.
+func New(name string, src []byte) *Lexer {
+	l := &Lexer{src: src, pos: Pos{Name: name, Line: 1, Col: 1}}
+	l.this, l.size = l.decode(0)
+	l.reset()
+	return l
+}
+
+// Next returns the next token. Once the end of the source is reached, a
+// token with a kind of KindEndOfText is returned.
+func (l *Lexer) Next() Token {
+	for {
+		if !l.eval() {
+			if l.this != eot {
+				pos, ch := l.pos, l.this
+				l.keep()
+				l.illegalAt(pos, false, "rune.unexpected", "unexpected "+quote(string(ch)))
+			}
+			return l.emit()
+		}
+		t := l.post(l.emit())
+		if t.Kind != kindNone {
+			return t
+		}
+	}
+}
+
+// All returns every token up to, but not including, the end of the source.
+func (l *Lexer) All() []Token {
+	var toks []Token
+	for {
+		t := l.Next()
+		if t.Kind == KindEndOfText {
+			return toks
+		}
+		toks = append(toks, t)
+	}
+}
+
+func (l *Lexer) decode(off int) (rune, int) {
+	if off >= len(l.src) {
+		return eot, 0
+	}
+	if c := l.src[off]; c < utf8.RuneSelf {
+		return rune(c), 1
+	}
+	return utf8.DecodeRune(l.src[off:])
+}
+
+func (l *Lexer) peek(i int) rune {
+	off := l.pos.Offset
+	for ; i > 0; i-- {
+		_, size := l.decode(off)
+		if size == 0 {
+			return eot
+		}
+		off += size
+	}
+	ch, _ := l.decode(off)
+	return ch
+}
+
+func (l *Lexer) next() {
+	if l.this == eot {
+		return
+	}
+	if l.this == '\n' {
+		l.pos.Line++
+		l.pos.Col = 1
+	} else {
+		l.pos.Col++
+	}
+	l.pos.Offset += l.size
+	l.this, l.size = l.decode(l.pos.Offset)
+}
+
+func (l *Lexer) keep() {
+	if l.this != eot {
+		l.val.add(l.src, l.this, l.pos.Offset, l.size)
+		l.lit.add(l.src, l.this, l.pos.Offset, l.size)
+		l.prev = l.this
+		l.next()
+	}
+}
+
+func (l *Lexer) skip() {
+	if l.this != eot {
+		l.lit.add(l.src, l.this, l.pos.Offset, l.size)
+		l.prev = l.this
+		l.next()
+	}
+}
+
+func (l *Lexer) discard() {
+	l.next()
+	l.reset()
+}
+
+func (l *Lexer) keepN(n int) {
+	for ; n > 0; n-- {
+		l.keep()
+	}
+}
+
+func (l *Lexer) keepTo(off int) {
+	for l.this != eot && l.pos.Offset < off {
+		l.keep()
+	}
+}
+
+func (l *Lexer) skipN(n int) {
+	for ; n > 0; n-- {
+		l.skip()
+	}
+}
+
+func (l *Lexer) skipTo(off int) {
+	for l.this != eot && l.pos.Offset < off {
+		l.skip()
+	}
+}
+
+// skipPast skips up to and including the next end rune.
+func (l *Lexer) skipPast(end rune) {
+	for l.this != eot && l.this != end {
+		l.skip()
+	}
+	l.skip()
+}
+
+func (l *Lexer) writeRune(ch rune) {
+	l.val.spill(l.src)
+	l.val.b = utf8.AppendRune(l.val.b, ch)
+}
+
+func (l *Lexer) writeByte(c byte) {
+	l.val.spill(l.src)
+	l.val.b = append(l.val.b, c)
+}
+
+func (l *Lexer) illegal(incomplete bool, code string, msg string) {
+	l.kind = KindIllegal
+	l.errs = append(l.errs, Error{
+		Pos:        l.pos,
+		Message:    msg,
+		Code:       code,
+		Incomplete: incomplete,
+	})
+}
+
+// illegalAt reports an error for the text from pos up to the current
+// position.
+func (l *Lexer) illegalAt(pos Pos, incomplete bool, code string, msg string) {
+	l.kind = KindIllegal
+	l.errs = append(l.errs, Error{
+		Pos:        pos,
+		End:        l.pos,
+		Message:    msg,
+		Code:       code,
+		Incomplete: incomplete,
+	})
+}
+
+// undo moves back to the start of the token and clears the value.
+func (l *Lexer) undo() {
+	l.pos = l.tok
+	l.this, l.size = l.decode(l.pos.Offset)
+	l.val.reset()
+	l.lit.reset()
+	l.prev = eot
+	l.kind = kindNone
+}
+
+func (l *Lexer) emit() Token {
+	t := Token{
+		Kind: l.kind,
+		Val:  string(l.val.bytes(l.src)),
+		Lit:  string(l.lit.bytes(l.src)),
+		Pos:  l.tok,
+		End:  l.pos,
+		Errs: l.errs,
+	}
+	if t.Val == "" && t.Lit == "" && l.this == eot {
+		t.Kind = KindEndOfText
+	}
+	l.reset()
+	return t
+}
+
+func (l *Lexer) reset() {
+	l.tok = l.pos
+	l.val.reset()
+	l.lit.reset()
+	l.prev = eot
+	l.kind = kindNone
+	l.errs = nil
+}
+
+func quote(s string) string {
+	var qs strings.Builder
+	for _, ch := range s {
+		switch {
+		case !unicode.IsPrint(ch) || ch == 0xfffd:
+			qs.WriteString(escape(ch))
+		default:
+			qs.WriteRune(ch)
+		}
+	}
+	switch {
+	case !strings.Contains(s, `"`):
+		return `"` + qs.String() + `"`
+	case !strings.Contains(s, `'`):
+		return `'` + qs.String() + `'`
+	case !strings.Contains(s, "`"):
+		return "`" + qs.String() + "`"
+	default:
+		return "{!quote:" + s + "}"
+	}
+}
+
+func escape(ch rune) string {
+	switch ch {
+	case '\a':
+		return "{!ch:\\a}"
+	case '\b':
+		return "{!ch:\\b}"
+	case '\f':
+		return "{!ch:\\f}"
+	case '\n':
+		return "{!ch:\\n}"
+	case '\r':
+		return "{!ch:\\r}"
+	case '\t':
+		return "{!ch:\\t}"
+	case '\v':
+		return "{!ch:\\v}"
+	}
+	switch {
+	case ch <= 0xff:
+		return fmt.Sprintf("{!ch:%02x}", ch)
+	case ch <= 0xffff:
+		return fmt.Sprintf("{!ch:%04x}", ch)
+	default:
+		return fmt.Sprintf("{!ch:%08x}", ch)
+	}
+}
+func class1(c rune) bool {
+	return c == ' ' || c == '\t' || c == '\r'
+}
+
+func (l *Lexer) rule0() bool {
+	if !class1(l.this) {
+		return false
+	}
+	for l.this != eot && class1(l.this) {
+		l.discard()
+	}
+	return true
+}
+
+func (l *Lexer) lit3() int {
+	n := 0
+	src := l.src[l.pos.Offset:]
+	if len(src) > 0 {
+		switch src[0] {
+		case '/':
+			if len(src) > 1 {
+				switch src[1] {
+				case '*':
+					n = 2
+				}
+			}
+		}
+	}
+	return n
+}
+
+func (l *Lexer) lit4() int {
+	n := 0
+	src := l.src[l.pos.Offset:]
+	if len(src) > 0 {
+		switch src[0] {
+		case '*':
+			if len(src) > 1 {
+				switch src[1] {
+				case '/':
+					n = 2
+				}
+			}
+		}
+	}
+	return n
+}
+
+func (l *Lexer) rule2() bool {
+	n := l.lit3()
+	if n == 0 {
+		return false
+	}
+	l.skipTo(l.pos.Offset + n)
+	for l.this != eot {
+		if n := l.lit4(); n > 0 {
+			l.skipTo(l.pos.Offset + n)
+			return true
+		}
+		l.skip()
+	}
+	l.illegal(true, "comment.unterminated", "comment not terminated")
+	return true
+}
+
+func (l *Lexer) lit6() int {
+	n := 0
+	src := l.src[l.pos.Offset:]
+	if len(src) > 0 {
+		switch src[0] {
+		case '/':
+			if len(src) > 1 {
+				switch src[1] {
+				case '/':
+					n = 2
+				}
+			}
+		}
+	}
+	return n
+}
+
+func (l *Lexer) lit7() int {
+	n := 0
+	src := l.src[l.pos.Offset:]
+	if len(src) > 0 {
+		switch src[0] {
+		case '\n':
+			n = 1
+		}
+	}
+	return n
+}
+
+func (l *Lexer) rule5() bool {
+	n := l.lit6()
+	if n == 0 {
+		return false
+	}
+	l.skipTo(l.pos.Offset + n)
+	for l.this != eot {
+		if n := l.lit7(); n > 0 {
+			l.skipTo(l.pos.Offset + n)
+			return true
+		}
+		l.skip()
+	}
+	return true
+}
+
+func (l *Lexer) esc8() bool {
+	var to rune
+	switch l.this {
+	case 'a':
+		to = '\a'
+	case 'b':
+		to = '\b'
+	case 'f':
+		to = '\f'
+	case 'n':
+		to = '\n'
+	case 'r':
+		to = '\r'
+	case 't':
+		to = '\t'
+	case 'v':
+		to = '\v'
+	default:
+		return false
+	}
+	l.writeRune(to)
+	l.skip()
+	return true
+}
+
+func (l *Lexer) esc9() bool {
+	if l.this != 'x' {
+		return false
+	}
+	l.skip()
+	start := l.pos
+	var digits [2]rune
+	var val uint32
+	for i := range digits {
+		ch := l.peek(i)
+		var d rune
+		switch {
+		case '0' <= ch && ch <= '9':
+			d = ch - '0'
+		case 'a' <= ch && ch <= 'f':
+			d = ch - 'a' + 10
+		case 'A' <= ch && ch <= 'F':
+			d = ch - 'A' + 10
+		default:
+			l.keepN(i)
+			l.illegalAt(start, false, "esc.invalid", "invalid encoding: "+quote(string(digits[:i])))
+			return true
+		}
+		digits[i] = ch
+		val = val<<4 | uint32(d)
+	}
+	if !utf8.ValidRune(rune(val)) {
+		l.keepN(len(digits))
+		l.illegalAt(start, false, "esc.invalid", "invalid encoding: "+quote(string(digits[:])))
+		return true
+	}
+	l.writeRune(rune(val))
+	l.skipN(len(digits))
+	return true
+}
+
+func (l *Lexer) esc10() bool {
+	if l.this != 'u' {
+		return false
+	}
+	l.skip()
+	start := l.pos
+	var digits [4]rune
+	var val uint32
+	for i := range digits {
+		ch := l.peek(i)
+		var d rune
+		switch {
+		case '0' <= ch && ch <= '9':
+			d = ch - '0'
+		case 'a' <= ch && ch <= 'f':
+			d = ch - 'a' + 10
+		case 'A' <= ch && ch <= 'F':
+			d = ch - 'A' + 10
+		default:
+			l.keepN(i)
+			l.illegalAt(start, false, "esc.invalid", "invalid encoding: "+quote(string(digits[:i])))
+			return true
+		}
+		digits[i] = ch
+		val = val<<4 | uint32(d)
+	}
+	if !utf8.ValidRune(rune(val)) {
+		l.keepN(len(digits))
+		l.illegalAt(start, false, "esc.invalid", "invalid encoding: "+quote(string(digits[:])))
+		return true
+	}
+	l.writeRune(rune(val))
+	l.skipN(len(digits))
+	return true
+}
+
+func (l *Lexer) esc11() bool {
+	if l.this != 'U' {
+		return false
+	}
+	l.skip()
+	start := l.pos
+	var digits [8]rune
+	var val uint32
+	for i := range digits {
+		ch := l.peek(i)
+		var d rune
+		switch {
+		case '0' <= ch && ch <= '9':
+			d = ch - '0'
+		case 'a' <= ch && ch <= 'f':
+			d = ch - 'a' + 10
+		case 'A' <= ch && ch <= 'F':
+			d = ch - 'A' + 10
+		default:
+			l.keepN(i)
+			l.illegalAt(start, false, "esc.invalid", "invalid encoding: "+quote(string(digits[:i])))
+			return true
+		}
+		digits[i] = ch
+		val = val<<4 | uint32(d)
+	}
+	if !utf8.ValidRune(rune(val)) {
+		l.keepN(len(digits))
+		l.illegalAt(start, false, "esc.invalid", "invalid encoding: "+quote(string(digits[:])))
+		return true
+	}
+	l.writeRune(rune(val))
+	l.skipN(len(digits))
+	return true
+}
+
+func (l *Lexer) esc12() bool {
+	if l.this < '0' || l.this > '7' {
+		return false
+	}
+	start := l.pos
+	var digits [3]rune
+	val := 0
+	for i := range digits {
+		ch := l.peek(i)
+		if ch < '0' || ch > '7' {
+			l.keepN(i)
+			l.illegalAt(start, false, "esc.invalid", "invalid encoding: "+quote(string(digits[:i])))
+			return true
+		}
+		digits[i] = ch
+		val = val<<3 | int(ch-'0')
+	}
+	if val > 0xff {
+		l.keepN(len(digits))
+		l.illegalAt(start, false, "esc.invalid", "invalid encoding: "+quote(string(digits[:])))
+		return true
+	}
+	l.skipN(len(digits))
+	l.writeRune(rune(val))
+	return true
+}
+
+func (l *Lexer) esc13() bool {
+	return l.esc8() || l.esc9() || l.esc10() || l.esc11() || l.esc12()
+}
+
+func (l *Lexer) rule14() bool {
+	if l.this != '\'' {
+		return false
+	}
+	l.skip()
+	l.kind = KindRune
+	length := 0
+	for l.this != eot {
+		if l.this == '\'' {
+			l.skip()
+			return true
+		}
+		length++
+		if length > 1 {
+			l.illegal(false, "str.too-long", fmt.Sprintf("too many characters (%v)", length))
+			l.skipPast('\'')
+			return true
+		}
+		switch {
+		case l.this == '\n':
+			l.illegal(false, "str.unterminated", "not terminated")
+			return true
+		case l.this == '\\':
+			l.skip()
+			if l.this == '\'' || l.this == '\\' {
+				l.keep()
+			} else if pos, ch := l.pos, l.this; !l.esc13() {
+				l.keep()
+				l.illegalAt(pos, false, "str.invalid-escape", fmt.Sprintf("invalid escape sequence: '%c%c'", '\\', ch))
+				l.skipPast('\'')
+				return true
+			} else if len(l.errs) > 0 {
+				l.skipPast('\'')
+				return true
+			}
+		default:
+			l.keep()
+		}
+	}
+	l.illegal(true, "str.unterminated", "not terminated")
+	return true
+}
+
+func class16(c rune) bool {
+	return c == 'i'
+}
+
+func (l *Lexer) rule15() bool {
+	if !class16(l.this) {
+		return false
+	}
+	l.keep()
+	l.kind = KindImag
+	return true
+}
+
+func class18(c rune) bool {
+	return '0' <= c && c <= '9' || 'a' <= c && c <= 'f' || 'A' <= c && c <= 'F'
+}
+
+func class19(c rune) bool {
+	return c == '_'
+}
+
+func class20(c rune) bool {
+	return c == '.'
+}
+
+func class21(c rune) bool {
+	return c == 'p' || c == 'P'
+}
+
+func class22(c rune) bool {
+	return c == '+' || c == '-'
+}
+
+func (l *Lexer) lit23() int {
+	n := 0
+	src := l.src[l.pos.Offset:]
+	if len(src) > 0 {
+		switch src[0] {
+		case '0':
+			if len(src) > 1 {
+				switch src[1] {
+				case 'X':
+					n = 2
+				case 'x':
+					n = 2
+				}
+			}
+		}
+	}
+	return n
+}
+
+func (l *Lexer) rule17Digits(seen bool) bool {
+	for l.this != eot {
+		switch {
+		case class18(l.this):
+			seen = true
+			l.keep()
+		case class19(l.this) && class18(l.peek(1)) && class18(l.prev):
+			l.skip()
+		default:
+			return seen
+		}
+	}
+	return seen
+}
+
+func (l *Lexer) rule17() bool {
+	n := l.lit23()
+	if n == 0 {
+		l.undo()
+		return false
+	}
+	l.keepTo(l.pos.Offset + n)
+	if class19(l.this) {
+		l.skip()
+	}
+	l.kind = KindInt
+	seen := l.rule17Digits(false)
+	if class20(l.this) {
+		l.kind = KindFloat
+		l.keep()
+		seen = l.rule17Digits(seen)
+	}
+	if !seen {
+		l.undo()
+		return false
+	}
+	if class21(l.this) && (class18(l.peek(1)) || class22(l.peek(1)) && class18(l.peek(2))) {
+		l.kind = KindFloat
+		l.keep()
+		if class22(l.this) {
+			l.keep()
+		}
+		l.rule17Digits(seen)
+	}
+	_ = l.rule15()
+	return true
+}
+
+func (l *Lexer) rule24() bool {
+	if !class16(l.this) {
+		return false
+	}
+	l.keep()
+	l.kind = KindImag
+	return true
+}
+
+func class26(c rune) bool {
+	return '0' <= c && c <= '7'
+}
+
+func (l *Lexer) lit27() int {
+	n := 0
+	src := l.src[l.pos.Offset:]
+	if len(src) > 0 {
+		switch src[0] {
+		case '0':
+			if len(src) > 1 {
+				switch src[1] {
+				case 'O':
+					n = 2
+				case 'o':
+					n = 2
+				}
+			}
+		}
+	}
+	return n
+}
+
+func (l *Lexer) rule25Digits(seen bool) bool {
+	for l.this != eot {
+		switch {
+		case class26(l.this):
+			seen = true
+			l.keep()
+		case class19(l.this) && class26(l.peek(1)) && class26(l.prev):
+			l.skip()
+		default:
+			return seen
+		}
+	}
+	return seen
+}
+
+func (l *Lexer) rule25() bool {
+	n := l.lit27()
+	if n == 0 {
+		l.undo()
+		return false
+	}
+	l.keepTo(l.pos.Offset + n)
+	if class19(l.this) {
+		l.skip()
+	}
+	l.kind = KindInt
+	seen := l.rule25Digits(false)
+	if !seen {
+		l.undo()
+		return false
+	}
+	_ = l.rule24()
+	return true
+}
+
+func (l *Lexer) rule28() bool {
+	if !class16(l.this) {
+		return false
+	}
+	l.keep()
+	l.kind = KindImag
+	return true
+}
+
+func class30(c rune) bool {
+	return c == '0' || c == '1'
+}
+
+func (l *Lexer) lit31() int {
+	n := 0
+	src := l.src[l.pos.Offset:]
+	if len(src) > 0 {
+		switch src[0] {
+		case '0':
+			if len(src) > 1 {
+				switch src[1] {
+				case 'B':
+					n = 2
+				case 'b':
+					n = 2
+				}
+			}
+		}
+	}
+	return n
+}
+
+func (l *Lexer) rule29Digits(seen bool) bool {
+	for l.this != eot {
+		switch {
+		case class30(l.this):
+			seen = true
+			l.keep()
+		case class19(l.this) && class30(l.peek(1)) && class30(l.prev):
+			l.skip()
+		default:
+			return seen
+		}
+	}
+	return seen
+}
+
+func (l *Lexer) rule29() bool {
+	n := l.lit31()
+	if n == 0 {
+		l.undo()
+		return false
+	}
+	l.keepTo(l.pos.Offset + n)
+	if class19(l.this) {
+		l.skip()
+	}
+	l.kind = KindInt
+	seen := l.rule29Digits(false)
+	if !seen {
+		l.undo()
+		return false
+	}
+	_ = l.rule28()
+	return true
+}
+
+func (l *Lexer) rule32() bool {
+	if !class16(l.this) {
+		return false
+	}
+	l.keep()
+	l.kind = KindImag
+	return true
+}
+
+func class34(c rune) bool {
+	return '0' <= c && c <= '9'
+}
+
+func class35(c rune) bool {
+	return c == 'e' || c == 'E'
+}
+
+func (l *Lexer) rule33Digits(seen bool) bool {
+	for l.this != eot {
+		switch {
+		case class34(l.this):
+			seen = true
+			l.keep()
+		case class19(l.this) && class34(l.peek(1)) && class34(l.prev):
+			l.skip()
+		default:
+			return seen
+		}
+	}
+	return seen
+}
+
+func (l *Lexer) rule33() bool {
+	l.kind = KindInt
+	seen := l.rule33Digits(false)
+	if class20(l.this) {
+		l.kind = KindFloat
+		l.keep()
+		seen = l.rule33Digits(seen)
+	}
+	if !seen {
+		l.undo()
+		return false
+	}
+	if class35(l.this) && (class34(l.peek(1)) || class22(l.peek(1)) && class34(l.peek(2))) {
+		l.kind = KindFloat
+		l.keep()
+		if class22(l.this) {
+			l.keep()
+		}
+		l.rule33Digits(seen)
+	}
+	_ = l.rule32()
+	return true
+}
+
+func (l *Lexer) esc36() bool {
+	var to rune
+	switch l.this {
+	case 'a':
+		to = '\a'
+	case 'b':
+		to = '\b'
+	case 'f':
+		to = '\f'
+	case 'n':
+		to = '\n'
+	case 'r':
+		to = '\r'
+	case 't':
+		to = '\t'
+	case 'v':
+		to = '\v'
+	default:
+		return false
+	}
+	l.writeRune(to)
+	l.skip()
+	return true
+}
+
+func (l *Lexer) esc37() bool {
+	if l.this != 'x' {
+		return false
+	}
+	l.skip()
+	start := l.pos
+	var digits [2]rune
+	var val uint32
+	for i := range digits {
+		ch := l.peek(i)
+		var d rune
+		switch {
+		case '0' <= ch && ch <= '9':
+			d = ch - '0'
+		case 'a' <= ch && ch <= 'f':
+			d = ch - 'a' + 10
+		case 'A' <= ch && ch <= 'F':
+			d = ch - 'A' + 10
+		default:
+			l.keepN(i)
+			l.illegalAt(start, false, "esc.invalid", "invalid encoding: "+quote(string(digits[:i])))
+			return true
+		}
+		digits[i] = ch
+		val = val<<4 | uint32(d)
+	}
+	l.writeByte(byte(val))
+	l.skipN(len(digits))
+	return true
+}
+
+func (l *Lexer) esc38() bool {
+	if l.this != 'u' {
+		return false
+	}
+	l.skip()
+	start := l.pos
+	var digits [4]rune
+	var val uint32
+	for i := range digits {
+		ch := l.peek(i)
+		var d rune
+		switch {
+		case '0' <= ch && ch <= '9':
+			d = ch - '0'
+		case 'a' <= ch && ch <= 'f':
+			d = ch - 'a' + 10
+		case 'A' <= ch && ch <= 'F':
+			d = ch - 'A' + 10
+		default:
+			l.keepN(i)
+			l.illegalAt(start, false, "esc.invalid", "invalid encoding: "+quote(string(digits[:i])))
+			return true
+		}
+		digits[i] = ch
+		val = val<<4 | uint32(d)
+	}
+	if !utf8.ValidRune(rune(val)) {
+		l.keepN(len(digits))
+		l.illegalAt(start, false, "esc.invalid", "invalid encoding: "+quote(string(digits[:])))
+		return true
+	}
+	l.writeRune(rune(val))
+	l.skipN(len(digits))
+	return true
+}
+
+func (l *Lexer) esc39() bool {
+	if l.this != 'U' {
+		return false
+	}
+	l.skip()
+	start := l.pos
+	var digits [8]rune
+	var val uint32
+	for i := range digits {
+		ch := l.peek(i)
+		var d rune
+		switch {
+		case '0' <= ch && ch <= '9':
+			d = ch - '0'
+		case 'a' <= ch && ch <= 'f':
+			d = ch - 'a' + 10
+		case 'A' <= ch && ch <= 'F':
+			d = ch - 'A' + 10
+		default:
+			l.keepN(i)
+			l.illegalAt(start, false, "esc.invalid", "invalid encoding: "+quote(string(digits[:i])))
+			return true
+		}
+		digits[i] = ch
+		val = val<<4 | uint32(d)
+	}
+	if !utf8.ValidRune(rune(val)) {
+		l.keepN(len(digits))
+		l.illegalAt(start, false, "esc.invalid", "invalid encoding: "+quote(string(digits[:])))
+		return true
+	}
+	l.writeRune(rune(val))
+	l.skipN(len(digits))
+	return true
+}
+
+func (l *Lexer) esc40() bool {
+	if l.this < '0' || l.this > '7' {
+		return false
+	}
+	start := l.pos
+	var digits [3]rune
+	val := 0
+	for i := range digits {
+		ch := l.peek(i)
+		if ch < '0' || ch > '7' {
+			l.keepN(i)
+			l.illegalAt(start, false, "esc.invalid", "invalid encoding: "+quote(string(digits[:i])))
+			return true
+		}
+		digits[i] = ch
+		val = val<<3 | int(ch-'0')
+	}
+	if val > 0xff {
+		l.keepN(len(digits))
+		l.illegalAt(start, false, "esc.invalid", "invalid encoding: "+quote(string(digits[:])))
+		return true
+	}
+	l.skipN(len(digits))
+	l.writeRune(rune(val))
+	return true
+}
+
+func (l *Lexer) esc41() bool {
+	return l.esc36() || l.esc37() || l.esc38() || l.esc39() || l.esc40()
+}
+
+func (l *Lexer) rule42() bool {
+	if l.this != '"' {
+		return false
+	}
+	l.skip()
+	l.kind = KindString
+	for l.this != eot {
+		if l.this == '"' {
+			l.skip()
+			return true
+		}
+		switch {
+		case l.this == '\n':
+			l.illegal(false, "str.unterminated", "not terminated")
+			return true
+		case l.this == '\\':
+			l.skip()
+			if l.this == '"' || l.this == '\\' {
+				l.keep()
+			} else if pos, ch := l.pos, l.this; !l.esc41() {
+				l.keep()
+				l.illegalAt(pos, false, "str.invalid-escape", fmt.Sprintf("invalid escape sequence: '%c%c'", '\\', ch))
+				l.skipPast('"')
+				return true
+			} else if len(l.errs) > 0 {
+				l.skipPast('"')
+				return true
+			}
+		default:
+			l.keep()
+		}
+	}
+	l.illegal(true, "str.unterminated", "not terminated")
+	return true
+}
+
+func (l *Lexer) esc43() bool {
+	return false
+}
+
+func (l *Lexer) rule44() bool {
+	if l.this != '`' {
+		return false
+	}
+	l.skip()
+	l.kind = KindString
+	for l.this != eot {
+		if l.this == '`' {
+			l.skip()
+			return true
+		}
+		switch {
+		case l.this == '\n':
+			l.keep()
+		case l.this == '\x00':
+			l.skip()
+			if l.this == '`' || l.this == '\x00' {
+				l.keep()
+			} else if pos, ch := l.pos, l.this; !l.esc43() {
+				l.keep()
+				l.illegalAt(pos, false, "str.invalid-escape", fmt.Sprintf("invalid escape sequence: '%c%c'", '\x00', ch))
+				l.skipPast('`')
+				return true
+			} else if len(l.errs) > 0 {
+				l.skipPast('`')
+				return true
+			}
+		default:
+			l.keep()
+		}
+	}
+	l.illegal(true, "str.unterminated", "not terminated")
+	return true
+}
+
+func class46(c rune) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_' || c >= utf8.RuneSelf && unicode.IsLetter(c)
+}
+
+func class47(c rune) bool {
+	return 'a' <= c && c <= 'z' || 'A' <= c && c <= 'Z' || c == '_' || '0' <= c && c <= '9' || c >= utf8.RuneSelf && (unicode.IsLetter(c) || unicode.IsDigit(c))
+}
+
+func (l *Lexer) rule45() bool {
+	if !class46(l.this) {
+		return false
+	}
+	l.keep()
+	for l.this != eot && class47(l.this) {
+		l.keep()
+	}
+	switch string(l.val.bytes(l.src)) {
+	case "break":
+		l.kind = KindBreak
+	case "case":
+		l.kind = KindCase
+	case "chan":
+		l.kind = KindChan
+	case "const":
+		l.kind = KindConst
+	case "continue":
+		l.kind = KindContinue
+	case "default":
+		l.kind = KindDefault
+	case "defer":
+		l.kind = KindDefer
+	case "else":
+		l.kind = KindElse
+	case "fallthrough":
+		l.kind = KindFallthrough
+	case "for":
+		l.kind = KindFor
+	case "func":
+		l.kind = KindFunc
+	case "go":
+		l.kind = KindGo
+	case "goto":
+		l.kind = KindGoto
+	case "if":
+		l.kind = KindIf
+	case "import":
+		l.kind = KindImport
+	case "interface":
+		l.kind = KindInterface
+	case "map":
+		l.kind = KindMap
+	case "package":
+		l.kind = KindPackage
+	case "range":
+		l.kind = KindRange
+	case "return":
+		l.kind = KindReturn
+	case "select":
+		l.kind = KindSelect
+	case "struct":
+		l.kind = KindStruct
+	case "switch":
+		l.kind = KindSwitch
+	case "type":
+		l.kind = KindType
+	case "var":
+		l.kind = KindVar
+	default:
+		l.kind = KindIdent
+	}
+	return true
+}
+
+func (l *Lexer) lit49() (int, Kind) {
+	n, k := 0, kindNone
+	src := l.src[l.pos.Offset:]
+	if len(src) > 0 {
+		switch src[0] {
+		case '\n':
+			n, k = 1, KindNewline
+		case '!':
+			n, k = 1, KindNot
+			if len(src) > 1 {
+				switch src[1] {
+				case '=':
+					n, k = 2, KindNotEq
+				}
+			}
+		case '%':
+			n, k = 1, KindPercent
+			if len(src) > 1 {
+				switch src[1] {
+				case '=':
+					n, k = 2, KindPercentEq
+				}
+			}
+		case '&':
+			n, k = 1, KindAmp
+			if len(src) > 1 {
+				switch src[1] {
+				case '&':
+					n, k = 2, KindAmpAmp
+				case '=':
+					n, k = 2, KindAmpEq
+				case '^':
+					n, k = 2, KindAmpCaret
+					if len(src) > 2 {
+						switch src[2] {
+						case '=':
+							n, k = 3, KindAmpCaretEq
+						}
+					}
+				}
+			}
+		case '(':
+			n, k = 1, KindLParen
+		case ')':
+			n, k = 1, KindRParen
+		case '*':
+			n, k = 1, KindStar
+			if len(src) > 1 {
+				switch src[1] {
+				case '=':
+					n, k = 2, KindStarEq
+				}
+			}
+		case '+':
+			n, k = 1, KindPlus
+			if len(src) > 1 {
+				switch src[1] {
+				case '+':
+					n, k = 2, KindPlusPlus
+				case '=':
+					n, k = 2, KindPlusEq
+				}
+			}
+		case ',':
+			n, k = 1, KindComma
+		case '-':
+			n, k = 1, KindMinus
+			if len(src) > 1 {
+				switch src[1] {
+				case '-':
+					n, k = 2, KindMinusMinus
+				case '=':
+					n, k = 2, KindMinusEq
+				}
+			}
+		case '.':
+			n, k = 1, KindDot
+			if len(src) > 1 {
+				switch src[1] {
+				case '.':
+					if len(src) > 2 {
+						switch src[2] {
+						case '.':
+							n, k = 3, KindDotDotDot
+						}
+					}
+				}
+			}
+		case '/':
+			n, k = 1, KindSlash
+			if len(src) > 1 {
+				switch src[1] {
+				case '=':
+					n, k = 2, KindSlashEq
+				}
+			}
+		case ':':
+			n, k = 1, KindColon
+			if len(src) > 1 {
+				switch src[1] {
+				case '=':
+					n, k = 2, KindColonEq
+				}
+			}
+		case ';':
+			n, k = 1, KindSemi
+		case '<':
+			n, k = 1, KindLt
+			if len(src) > 1 {
+				switch src[1] {
+				case '-':
+					n, k = 2, KindLtMinus
+				case '<':
+					n, k = 2, KindLtLt
+					if len(src) > 2 {
+						switch src[2] {
+						case '=':
+							n, k = 3, KindLtLtEq
+						}
+					}
+				case '=':
+					n, k = 2, KindLtEq
+				}
+			}
+		case '=':
+			n, k = 1, KindEq
+			if len(src) > 1 {
+				switch src[1] {
+				case '=':
+					n, k = 2, KindEqEq
+				}
+			}
+		case '>':
+			n, k = 1, KindGt
+			if len(src) > 1 {
+				switch src[1] {
+				case '=':
+					n, k = 2, KindGtEq
+				case '>':
+					n, k = 2, KindGtGt
+					if len(src) > 2 {
+						switch src[2] {
+						case '=':
+							n, k = 3, KindGtGtEq
+						}
+					}
+				}
+			}
+		case '[':
+			n, k = 1, KindLBrack
+		case ']':
+			n, k = 1, KindRBrack
+		case '^':
+			n, k = 1, KindCaret
+			if len(src) > 1 {
+				switch src[1] {
+				case '=':
+					n, k = 2, KindCaretEq
+				}
+			}
+		case '{':
+			n, k = 1, KindLBrace
+		case '|':
+			n, k = 1, KindPipe
+			if len(src) > 1 {
+				switch src[1] {
+				case '=':
+					n, k = 2, KindPipeEq
+				case '|':
+					n, k = 2, KindPipePipe
+				}
+			}
+		case '}':
+			n, k = 1, KindRBrace
+		case '~':
+			n, k = 1, KindTilde
+		}
+	}
+	return n, k
+}
+
+func (l *Lexer) rule48() bool {
+	n, k := l.lit49()
+	if n == 0 {
+		return false
+	}
+	l.keepTo(l.pos.Offset + n)
+	l.kind = k
+	return true
+}
+
+func (l *Lexer) eval() bool {
+	if l.this >= utf8.RuneSelf {
+		return l.rule45()
+	}
+	switch l.this {
+	case '\t', '\r', ' ':
+		return l.rule0()
+	case '\n', '!', '%', '&', '(', ')', '*', '+', ',', '-', ':', ';', '<', '=', '>', '[', ']', '^', '{', '|', '}', '~':
+		return l.rule48()
+	case '"':
+		return l.rule42()
+	case '\'':
+		return l.rule14()
+	case '.':
+		return l.rule33() || l.rule48()
+	case '/':
+		return l.rule2() || l.rule5() || l.rule48()
+	case '0':
+		return l.rule17() || l.rule25() || l.rule29() || l.rule33()
+	case '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return l.rule33()
+	case 'A', 'B', 'C', 'D', 'E', 'F', 'G', 'H', 'I', 'J', 'K', 'L', 'M', 'N', 'O', 'P', 'Q', 'R', 'S', 'T', 'U', 'V', 'W', 'X', 'Y', 'Z', '_', 'a', 'b', 'c', 'd', 'e', 'f', 'g', 'h', 'i', 'j', 'k', 'l', 'm', 'n', 'o', 'p', 'q', 'r', 's', 't', 'u', 'v', 'w', 'x', 'y', 'z':
+		return l.rule45()
+	case '`':
+		return l.rule44()
+	}
+	return false
+}
+
+func (l *Lexer) post(t Token) Token {
+	if t.Kind == KindNewline {
+		switch l.last {
+		case KindIdent, KindInt, KindFloat, KindImag, KindRune, KindString, KindBreak, KindContinue, KindFallthrough, KindReturn, KindPlusPlus, KindMinusMinus, KindRParen, KindRBrack, KindRBrace:
+			t.Kind, t.Val = KindSemi, ";"
+		default:
+			t.Kind, t.Val = kindNone, ""
+		}
+	}
+	l.last = t.Kind
+	return t
+}
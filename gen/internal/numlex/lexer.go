@@ -0,0 +1,463 @@
+// Code generated by scan-gen. DO NOT EDIT.
+
+// Package numlex is a lexer for num.
+package numlex
+
+import (
+	"fmt"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Kind is the type of a token.
+type Kind int
+
+const (
+	kindNone Kind = iota
+	KindEndOfText
+	KindIllegal
+	KindInt
+	KindReal
+)
+
+var kindTypes = [...]string{
+	kindNone:      "",
+	KindEndOfText: "end-of-text",
+	KindIllegal:   "illegal",
+	KindInt:       "int",
+	KindReal:      "real",
+}
+
+// String returns the token type used by the rule set.
+func (k Kind) String() string {
+	return kindTypes[k]
+}
+
+const eot = rune(-1)
+
+// Pos is a position in the source. Offset is the number of bytes from the
+// start of the source.
+type Pos struct {
+	Name   string
+	Line   int
+	Col    int
+	Offset int
+}
+
+func (p Pos) String() string {
+	if p.Name != "" {
+		return fmt.Sprintf("%v:%v:%v", p.Name, p.Line, p.Col)
+	}
+	return fmt.Sprintf("%v:%v", p.Line, p.Col)
+}
+
+// Error is a problem found in the source. End is the position just after
+// the text with the problem, if known. Incomplete is true when the source
+// ended before the token was complete.
+type Error struct {
+	Pos        Pos
+	End        Pos
+	Message    string
+	Code       string
+	Incomplete bool
+}
+
+func (e Error) Error() string {
+	return fmt.Sprintf("%v: error: %v", e.Pos, e.Message)
+}
+
+// Token is a value returned by the lexer. Pos is the position of the first
+// rune in the token and End is the position just after the last rune.
+type Token struct {
+	Kind Kind
+	Val  string
+	Lit  string
+	Pos  Pos
+	End  Pos
+	Errs []Error
+}
+
+// buffer collects the bytes of a token value or literal. Bytes added in the
+// same order that they appear in the source are tracked as a slice of the
+// source until the contents diverge.
+type buffer struct {
+	start  int
+	end    int
+	copied bool
+	b      []byte
+}
+
+func (b *buffer) add(src []byte, ch rune, off int, width int) {
+	if !b.copied && !(ch == utf8.RuneError && width == 1) {
+		switch {
+		case b.start == b.end:
+			b.start, b.end = off, off+width
+			return
+		case b.end == off:
+			b.end += width
+			return
+		}
+	}
+	b.spill(src)
+	b.b = utf8.AppendRune(b.b, ch)
+}
+
+func (b *buffer) spill(src []byte) {
+	if b.copied {
+		return
+	}
+	b.copied = true
+	b.b = append(b.b[:0], src[b.start:b.end]...)
+}
+
+func (b *buffer) bytes(src []byte) []byte {
+	if !b.copied {
+		return src[b.start:b.end]
+	}
+	return b.b
+}
+
+func (b *buffer) reset() {
+	b.start, b.end = 0, 0
+	b.copied = false
+	b.b = b.b[:0]
+}
+
+// Lexer splits a source into tokens.
+type Lexer struct {
+	src  []byte
+	this rune
+	size int
+	prev rune
+	pos  Pos
+	tok  Pos
+	val  buffer
+	lit  buffer
+	kind Kind
+	errs []Error
+	last Kind
+}
+
+// New returns a lexer for src. The name is used in the positions of the
+// tokens.
+func New(name string, src []byte) *Lexer {
+	l := &Lexer{src: src, pos: Pos{Name: name, Line: 1, Col: 1}}
+	l.this, l.size = l.decode(0)
+	l.reset()
+	return l
+}
+
+// Next returns the next token. Once the end of the source is reached, a
+// token with a kind of KindEndOfText is returned.
+func (l *Lexer) Next() Token {
+	for {
+		if !l.eval() {
+			if l.this != eot {
+				pos, ch := l.pos, l.this
+				l.keep()
+				l.illegalAt(pos, false, "rune.unexpected", "unexpected "+quote(string(ch)))
+			}
+			return l.emit()
+		}
+		t := l.post(l.emit())
+		if t.Kind != kindNone {
+			return t
+		}
+	}
+}
+
+// All returns every token up to, but not including, the end of the source.
+func (l *Lexer) All() []Token {
+	var toks []Token
+	for {
+		t := l.Next()
+		if t.Kind == KindEndOfText {
+			return toks
+		}
+		toks = append(toks, t)
+	}
+}
+
+func (l *Lexer) decode(off int) (rune, int) {
+	if off >= len(l.src) {
+		return eot, 0
+	}
+	if c := l.src[off]; c < utf8.RuneSelf {
+		return rune(c), 1
+	}
+	return utf8.DecodeRune(l.src[off:])
+}
+
+func (l *Lexer) peek(i int) rune {
+	off := l.pos.Offset
+	for ; i > 0; i-- {
+		_, size := l.decode(off)
+		if size == 0 {
+			return eot
+		}
+		off += size
+	}
+	ch, _ := l.decode(off)
+	return ch
+}
+
+func (l *Lexer) next() {
+	if l.this == eot {
+		return
+	}
+	if l.this == '\n' {
+		l.pos.Line++
+		l.pos.Col = 1
+	} else {
+		l.pos.Col++
+	}
+	l.pos.Offset += l.size
+	l.this, l.size = l.decode(l.pos.Offset)
+}
+
+func (l *Lexer) keep() {
+	if l.this != eot {
+		l.val.add(l.src, l.this, l.pos.Offset, l.size)
+		l.lit.add(l.src, l.this, l.pos.Offset, l.size)
+		l.prev = l.this
+		l.next()
+	}
+}
+
+func (l *Lexer) skip() {
+	if l.this != eot {
+		l.lit.add(l.src, l.this, l.pos.Offset, l.size)
+		l.prev = l.this
+		l.next()
+	}
+}
+
+func (l *Lexer) discard() {
+	l.next()
+	l.reset()
+}
+
+func (l *Lexer) keepN(n int) {
+	for ; n > 0; n-- {
+		l.keep()
+	}
+}
+
+func (l *Lexer) keepTo(off int) {
+	for l.this != eot && l.pos.Offset < off {
+		l.keep()
+	}
+}
+
+func (l *Lexer) skipN(n int) {
+	for ; n > 0; n-- {
+		l.skip()
+	}
+}
+
+func (l *Lexer) skipTo(off int) {
+	for l.this != eot && l.pos.Offset < off {
+		l.skip()
+	}
+}
+
+// skipPast skips up to and including the next end rune.
+func (l *Lexer) skipPast(end rune) {
+	for l.this != eot && l.this != end {
+		l.skip()
+	}
+	l.skip()
+}
+
+func (l *Lexer) writeRune(ch rune) {
+	l.val.spill(l.src)
+	l.val.b = utf8.AppendRune(l.val.b, ch)
+}
+
+func (l *Lexer) writeByte(c byte) {
+	l.val.spill(l.src)
+	l.val.b = append(l.val.b, c)
+}
+
+func (l *Lexer) illegal(incomplete bool, code string, msg string) {
+	l.kind = KindIllegal
+	l.errs = append(l.errs, Error{
+		Pos:        l.pos,
+		Message:    msg,
+		Code:       code,
+		Incomplete: incomplete,
+	})
+}
+
+// illegalAt reports an error for the text from pos up to the current
+// position.
+func (l *Lexer) illegalAt(pos Pos, incomplete bool, code string, msg string) {
+	l.kind = KindIllegal
+	l.errs = append(l.errs, Error{
+		Pos:        pos,
+		End:        l.pos,
+		Message:    msg,
+		Code:       code,
+		Incomplete: incomplete,
+	})
+}
+
+// undo moves back to the start of the token and clears the value.
+func (l *Lexer) undo() {
+	l.pos = l.tok
+	l.this, l.size = l.decode(l.pos.Offset)
+	l.val.reset()
+	l.lit.reset()
+	l.prev = eot
+	l.kind = kindNone
+}
+
+func (l *Lexer) emit() Token {
+	t := Token{
+		Kind: l.kind,
+		Val:  string(l.val.bytes(l.src)),
+		Lit:  string(l.lit.bytes(l.src)),
+		Pos:  l.tok,
+		End:  l.pos,
+		Errs: l.errs,
+	}
+	if t.Val == "" && t.Lit == "" && l.this == eot {
+		t.Kind = KindEndOfText
+	}
+	l.reset()
+	return t
+}
+
+func (l *Lexer) reset() {
+	l.tok = l.pos
+	l.val.reset()
+	l.lit.reset()
+	l.prev = eot
+	l.kind = kindNone
+	l.errs = nil
+}
+
+func quote(s string) string {
+	var qs strings.Builder
+	for _, ch := range s {
+		switch {
+		case !unicode.IsPrint(ch) || ch == 0xfffd:
+			qs.WriteString(escape(ch))
+		default:
+			qs.WriteRune(ch)
+		}
+	}
+	switch {
+	case !strings.Contains(s, `"`):
+		return `"` + qs.String() + `"`
+	case !strings.Contains(s, `'`):
+		return `'` + qs.String() + `'`
+	case !strings.Contains(s, "`"):
+		return "`" + qs.String() + "`"
+	default:
+		return "{!quote:" + s + "}"
+	}
+}
+
+func escape(ch rune) string {
+	switch ch {
+	case '\a':
+		return "{!ch:\\a}"
+	case '\b':
+		return "{!ch:\\b}"
+	case '\f':
+		return "{!ch:\\f}"
+	case '\n':
+		return "{!ch:\\n}"
+	case '\r':
+		return "{!ch:\\r}"
+	case '\t':
+		return "{!ch:\\t}"
+	case '\v':
+		return "{!ch:\\v}"
+	}
+	switch {
+	case ch <= 0xff:
+		return fmt.Sprintf("{!ch:%02x}", ch)
+	case ch <= 0xffff:
+		return fmt.Sprintf("{!ch:%04x}", ch)
+	default:
+		return fmt.Sprintf("{!ch:%08x}", ch)
+	}
+}
+func class1(c rune) bool {
+	return c == ' ' || c == '\n'
+}
+
+func (l *Lexer) rule0() bool {
+	if !class1(l.this) {
+		return false
+	}
+	for l.this != eot && class1(l.this) {
+		l.discard()
+	}
+	return true
+}
+
+func class3(c rune) bool {
+	return '0' <= c && c <= '9'
+}
+
+func class4(c rune) bool {
+	return c == '.'
+}
+
+func class5(c rune) bool {
+	return c == 'e'
+}
+
+func (l *Lexer) rule2Digits(seen bool) bool {
+	for l.this != eot {
+		switch {
+		case class3(l.this):
+			seen = true
+			l.keep()
+		default:
+			return seen
+		}
+	}
+	return seen
+}
+
+func (l *Lexer) rule2() bool {
+	l.kind = KindInt
+	seen := l.rule2Digits(false)
+	if class4(l.this) {
+		l.kind = KindReal
+		l.keep()
+		seen = l.rule2Digits(seen)
+	}
+	if !seen {
+		l.undo()
+		return false
+	}
+	if class5(l.this) && (class3(l.peek(1))) {
+		l.kind = KindReal
+		l.keep()
+		l.rule2Digits(seen)
+	}
+	return true
+}
+
+func (l *Lexer) eval() bool {
+	if l.this >= utf8.RuneSelf {
+		return false
+	}
+	switch l.this {
+	case '\n', ' ':
+		return l.rule0()
+	case '.', '0', '1', '2', '3', '4', '5', '6', '7', '8', '9':
+		return l.rule2()
+	}
+	return false
+}
+
+func (l *Lexer) post(t Token) Token {
+	return t
+}
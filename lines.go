@@ -0,0 +1,232 @@
+package scan
+
+import (
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// LineState is the state of the scanner at the end of a line when using
+// RuleSet.ScanLine. It contains the mode stack and the rule, if any, that
+// was in the middle of a match when the end of the line was reached. The
+// zero value is the state at the start of the input.
+//
+// States can be compared with == and can be cached for the start of each
+// line. If the state at the end of a line does not change after the line
+// has been edited, the lines that follow do not need to be scanned again.
+// A state can be saved as text with MarshalText and restored with
+// UnmarshalText.
+type LineState struct {
+	modes string
+	rule  string
+	data  string
+}
+
+// MarshalText encodes the state as text. The zero value is encoded as an
+// empty string.
+func (ls LineState) MarshalText() ([]byte, error) {
+	if ls == (LineState{}) {
+		return []byte{}, nil
+	}
+	text := strconv.Quote(ls.modes) + " " + strconv.Quote(ls.rule) + " " + strconv.Quote(ls.data)
+	return []byte(text), nil
+}
+
+// UnmarshalText decodes a state that was encoded with MarshalText.
+func (ls *LineState) UnmarshalText(text []byte) error {
+	if len(text) == 0 {
+		*ls = LineState{}
+		return nil
+	}
+	var fields [3]string
+	rest := string(text)
+	for i := range fields {
+		if i > 0 {
+			var ok bool
+			if rest, ok = strings.CutPrefix(rest, " "); !ok {
+				return fmt.Errorf("invalid line state: %q", text)
+			}
+		}
+		q, err := strconv.QuotedPrefix(rest)
+		if err != nil {
+			return fmt.Errorf("invalid line state: %q", text)
+		}
+		fields[i], _ = strconv.Unquote(q)
+		rest = rest[len(q):]
+	}
+	if rest != "" {
+		return fmt.Errorf("invalid line state: %q", text)
+	}
+	*ls = LineState{modes: fields[0], rule: fields[1], data: fields[2]}
+	return nil
+}
+
+// Resumer is implemented by rules that can match text spanning more than
+// one line, such as a block comment. When a rule reaches the end of a line
+// while still matching, it calls Scanner.Suspend with the data it needs to
+// continue. Resume is then called at the start of the next line with that
+// data.
+type Resumer interface {
+	Resume(s *Scanner, data string) bool
+}
+
+// suspension is the rule that called Suspend. The path contains the index
+// of the rule in each rule set that was being evaluated, starting from the
+// innermost rule set.
+type suspension struct {
+	data string
+	path []int
+}
+
+// Suspend is called by a rule that has reached the end of the input while
+// in the middle of a match that could continue on the next line. When the
+// scanner is being used by RuleSet.ScanLine, the data is saved to be passed
+// to Resume on the next line and true is returned. The rule should then
+// return true without reporting an error. Otherwise, false is returned and
+// the rule should handle the end of the input as usual. False is also
+// returned when the rule was not found in a rule set directly, or through
+// rules that forward Resume, as the match could not be resumed.
+func (s *Scanner) Suspend(data string) bool {
+	if !s.lines || !s.resumable {
+		return false
+	}
+	s.suspend = &suspension{data: data}
+	return true
+}
+
+// evalResumable evaluates rule, which is a rule in a rule set, and notes
+// whether it can be resumed if it suspends.
+func (s *Scanner) evalResumable(rule Rule) bool {
+	if !s.lines {
+		return rule.Eval(s)
+	}
+	prev := s.resumable
+	if _, ok := rule.(RuleSet); !ok {
+		s.resumable = prev && canResume(rule)
+	}
+	ok := rule.Eval(s)
+	s.resumable = prev
+	return ok
+}
+
+// canResume returns true if rule is a Resumer. Rules that wrap another rule
+// can only be resumed if the rule they wrap can be resumed.
+func canResume(rule Rule) bool {
+	switch r := rule.(type) {
+	case LabelRule:
+		return canResume(r.rule)
+	case ModeRule:
+		return canResume(r.rule)
+	case TypedRule:
+		return canResume(r.rule)
+	case RuleSet:
+		return false
+	}
+	_, ok := rule.(Resumer)
+	return ok
+}
+
+// suspended adds the index of the rule at position i of the candidates to
+// the path of the rule that was suspended, if any.
+func (s *Scanner) suspended(i int, index []int) {
+	if s.suspend == nil {
+		return
+	}
+	if index != nil {
+		i = index[i]
+	}
+	s.suspend.path = append(slices.Clip(s.suspend.path), i)
+}
+
+// ScanLine scans a single line of input starting from state. It returns the
+// tokens found in the line and the state at the end of the line that should
+// be used when scanning the next line. The line should include the newline
+// at the end, if any. The positions of the tokens are relative to the start
+// of the line.
+//
+// A match that spans more than one line, such as a block comment, is
+// returned as a separate token for each line. Only rules that implement
+// Resumer can continue a match from one line to the next.
+func (rules RuleSet) ScanLine(line string, state LineState) ([]Token, LineState) {
+	var s Scanner
+	s.InitFromString("", line)
+	s.lines = true
+	s.resumable = true
+	if state.modes != "" {
+		s.modes = strings.Split(state.modes, "\x00")
+	}
+
+	var toks []Token
+	if state.rule != "" && s.HasMore() {
+		if tok, ok := rules.resume(&s, state); ok && tok.IsValid() {
+			toks = append(toks, tok)
+		}
+	}
+	for s.HasMore() {
+		tok := rules.Next(&s)
+		if tok.IsEndOfText() {
+			break
+		}
+		toks = append(toks, tok)
+	}
+
+	var end LineState
+	end.modes = strings.Join(s.modes, "\x00")
+	if s.suspend != nil {
+		path := make([]string, len(s.suspend.path))
+		for i, idx := range s.suspend.path {
+			path[len(path)-1-i] = strconv.Itoa(idx)
+		}
+		end.rule = strings.Join(path, ".")
+		end.data = s.suspend.data
+	}
+	return toks, end
+}
+
+// resume continues the match of the rule found in state. False is returned
+// if the rule cannot be found or cannot be resumed.
+func (rules RuleSet) resume(s *Scanner, state LineState) (Token, bool) {
+	rs, ok := rules.Mode(s.Mode())
+	if !ok {
+		return Token{}, false
+	}
+	var rule Rule = rs
+	for _, p := range strings.Split(state.rule, ".") {
+		set, ok := rule.(RuleSet)
+		if !ok {
+			return Token{}, false
+		}
+		idx, err := strconv.Atoi(p)
+		if err != nil || idx < 0 || idx >= len(set.rules) {
+			return Token{}, false
+		}
+		rule = set.rules[idx]
+	}
+	r, ok := rule.(Resumer)
+	if !ok {
+		return Token{}, false
+	}
+	if !r.Resume(s, state.data) {
+		return Token{}, false
+	}
+	if s.suspend != nil {
+		s.suspend.path = pathOf(state.rule)
+	}
+	tok := s.Emit()
+	if rs.postTokenFunc != nil {
+		tok = rs.postTokenFunc(s, tok)
+	}
+	return tok, true
+}
+
+// pathOf converts the path of rule indexes in a line state back to the
+// innermost first order used by a suspension.
+func pathOf(rule string) []int {
+	parts := strings.Split(rule, ".")
+	path := make([]int, len(parts))
+	for i, p := range parts {
+		path[len(parts)-1-i], _ = strconv.Atoi(p)
+	}
+	return path
+}
@@ -0,0 +1,222 @@
+package scan
+
+import (
+	"strings"
+	"testing"
+)
+
+func scanLines(rules RuleSet, src string) ([][]string, []LineState) {
+	var toks [][]string
+	var states []LineState
+	var state LineState
+	for _, line := range strings.SplitAfter(src, "\n") {
+		var ts []Token
+		ts, state = rules.ScanLine(line, state)
+		var vals []string
+		for _, t := range ts {
+			vals = append(vals, t.Type+":"+t.Val)
+		}
+		toks = append(toks, vals)
+		states = append(states, state)
+	}
+	return toks, states
+}
+
+func TestScanLine(t *testing.T) {
+	rules := NewRuleSet(
+		SkipSpaceRule,
+		NewCommentRule(Literal("/*"), Literal("*/")).WithKeep(true),
+		NewCommentRule(Literal("//"), Literal("\n")).WithKeep(true),
+		NewStrRule('`', '`').WithMultiline(true),
+		NewStrRule('{', '}').WithMultiline(true).WithNesting(true).WithType("block"),
+		StrDoubleQuoteRule,
+		StandardIdentRule,
+	)
+	tests := []struct {
+		src     string
+		toks    [][]string
+		pending []bool
+	}{
+		{
+			"a /* b\nc\nd */ e\n",
+			[][]string{{"ident:a", "comment: b\n"}, {"comment:c\n"}, {"comment:d ", "ident:e"}, nil},
+			[]bool{true, true, false, false},
+		},
+		{
+			"`a\n\nb` c",
+			[][]string{{"str:a\n"}, {"str:\n"}, {"str:b", "ident:c"}},
+			[]bool{true, true, false},
+		},
+		{
+			"{a {b\nc} d} e",
+			[][]string{{"block:a {b\n"}, {"block:c} d", "ident:e"}},
+			[]bool{true, false},
+		},
+		{
+			"a // b",
+			[][]string{{"ident:a", "comment: b"}},
+			[]bool{false},
+		},
+		{
+			"\"a\nb\"",
+			[][]string{{"illegal:a"}, {"ident:b", "illegal:"}},
+			[]bool{false, false},
+		},
+	}
+	for _, test := range tests {
+		toks, states := scanLines(rules, test.src)
+		if len(toks) != len(test.toks) {
+			t.Fatalf("%q:\n have: %v \n want: %v", test.src, toks, test.toks)
+		}
+		for i := range toks {
+			have := strings.Join(toks[i], " ")
+			want := strings.Join(test.toks[i], " ")
+			if have != want {
+				t.Errorf("%q line %v:\n have: %q \n want: %q", test.src, i+1, have, want)
+			}
+			pending := states[i] != LineState{}
+			if pending != test.pending[i] {
+				t.Errorf("%q line %v: unexpected state %+v", test.src, i+1, states[i])
+			}
+		}
+	}
+}
+
+func TestScanLineStates(t *testing.T) {
+	rules := NewRuleSet(
+		SkipSpaceRule,
+		NewCommentRule(Literal("/*"), Literal("*/")),
+		NewCommentRule(Literal("(*"), Literal("*)")),
+		StandardIdentRule,
+	)
+	_, s1 := rules.ScanLine("a /* b\n", LineState{})
+	_, s2 := rules.ScanLine("c /* d\n", LineState{})
+	_, s3 := rules.ScanLine("e (* f\n", LineState{})
+	if s1 != s2 {
+		t.Errorf("states should be equal: %+v %+v", s1, s2)
+	}
+	if s1 == s3 {
+		t.Errorf("states should differ: %+v %+v", s1, s3)
+	}
+
+	// The state from s3 must resume the second comment rule
+	toks, s4 := rules.ScanLine("g */ *) h\n", s3)
+	if len(toks) != 1 || toks[0].Val != "h" || s4 != (LineState{}) {
+		t.Errorf("unexpected tokens %v with state %+v", toks, s4)
+	}
+}
+
+func TestScanLineModes(t *testing.T) {
+	tick := Literal("`")
+	rules := NewRuleSet(
+		SkipSpaceRule,
+		StandardIdentRule,
+		NewModeRule(tick).WithPush("template"),
+	).WithMode("template", NewRuleSet(
+		NewModeRule(tick).WithPop(true),
+		NewModeRule(Literal("${")).WithPush("expr"),
+		NewWhileRule(Not(Rune('`', '$')), StrType),
+	)).WithMode("expr", NewRuleSet(
+		SkipSpaceRule,
+		StandardIdentRule,
+		NewModeRule(Literal("}")).WithPop(true),
+	))
+
+	toks, states := scanLines(rules, "a `b ${c\nd} e\n` f")
+	want := [][]string{
+		{"ident:a", "`:`", "str:b ", "${:${", "ident:c"},
+		{"ident:d", "}:}", "str: e\n"},
+		{"`:`", "ident:f"},
+	}
+	for i := range want {
+		have := strings.Join(toks[i], " ")
+		if have != strings.Join(want[i], " ") {
+			t.Errorf("line %v:\n have: %q \n want: %q", i+1, have, want[i])
+		}
+	}
+	if states[0] == states[1] || states[2] != (LineState{}) {
+		t.Errorf("unexpected states: %+v", states)
+	}
+}
+
+func TestScanLineWrapped(t *testing.T) {
+	raw := NewStrRule('`', '`').WithMultiline(true)
+	rules := NewRuleSet(
+		SkipSpaceRule,
+		Typed(raw, "raw"),
+		NewModeRule(NewCommentRule(Literal("/*"), Literal("*/")).WithKeep(true)).WithPush("after"),
+		Seq(Literal("#"), NewStrRule('\'', '\'').WithMultiline(true)),
+		StandardIdentRule,
+	).WithMode("after", NewRuleSet(
+		SkipSpaceRule,
+		NewModeRule(Typed(StandardIdentRule, "after")).WithPop(true),
+	))
+	tests := []struct {
+		src     string
+		toks    [][]string
+		pending []bool
+	}{
+		{
+			"def `a\nb` y",
+			[][]string{{"ident:def", "raw:a\n"}, {"raw:b", "ident:y"}},
+			[]bool{true, false},
+		},
+		{
+			"a /* b\nc */ d e",
+			[][]string{{"ident:a", "comment: b\n"}, {"comment:c ", "after:d", "ident:e"}},
+			[]bool{true, false},
+		},
+		{
+			// A rule inside a sequence cannot be resumed so it is not
+			// suspended
+			"#'a\nb",
+			[][]string{{"illegal:#a\n"}, {"ident:b"}},
+			[]bool{false, false},
+		},
+	}
+	for _, test := range tests {
+		toks, states := scanLines(rules, test.src)
+		for i := range test.toks {
+			have := strings.Join(toks[i], " ")
+			want := strings.Join(test.toks[i], " ")
+			if have != want {
+				t.Errorf("%q line %v:\n have: %q \n want: %q", test.src, i+1, have, want)
+			}
+			pending := states[i] != LineState{}
+			if pending != test.pending[i] {
+				t.Errorf("%q line %v: unexpected state %+v", test.src, i+1, states[i])
+			}
+		}
+	}
+}
+
+func TestLineStateText(t *testing.T) {
+	rules := NewRuleSet(
+		SkipSpaceRule,
+		NewStrRule('{', '}').WithMultiline(true).WithNesting(true),
+		StandardIdentRule,
+		NewModeRule(Literal("<")).WithPush("a b\"c"),
+	).WithMode("a b\"c", NewRuleSet(
+		NewStrRule('{', '}').WithMultiline(true).WithNesting(true),
+	))
+	_, state := rules.ScanLine("< {x {y\n", LineState{})
+	for _, ls := range []LineState{{}, state} {
+		text, err := ls.MarshalText()
+		if err != nil {
+			t.Fatal(err)
+		}
+		var have LineState
+		if err := have.UnmarshalText(text); err != nil {
+			t.Fatal(err)
+		}
+		if have != ls {
+			t.Errorf("\n have: %+v \n want: %+v", have, ls)
+		}
+	}
+	for _, text := range []string{"x", `"a" "b"`, `"a" "b" "c" `} {
+		var ls LineState
+		if err := ls.UnmarshalText([]byte(text)); err == nil {
+			t.Errorf("%q: expected error", text)
+		}
+	}
+}
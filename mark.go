@@ -0,0 +1,156 @@
+package scan
+
+import "slices"
+
+// Mark is a checkpoint of the scanner state that is created with
+// Scanner.Mark and restored with Scanner.Reset.
+type Mark struct {
+	gen     int
+	idx     int
+	pos     Pos
+	tokPos  Pos
+	tokIdx  int
+	val     bufferMark
+	lit     bufferMark
+	type_   string
+	errs    Errors
+	modes   []string
+	trivia  []Token
+	suspend *suspension
+}
+
+type bufferMark struct {
+	start  int
+	end    int
+	copied bool
+	text   string
+}
+
+func (b *Buffer) mark() bufferMark {
+	m := bufferMark{start: b.start, end: b.end, copied: b.copied}
+	if b.copied {
+		m.text = b.b.String()
+	}
+	return m
+}
+
+func (b *Buffer) reset(m bufferMark) {
+	b.start = m.start
+	b.end = m.end
+	b.copied = m.copied
+	b.b.Reset()
+	if m.copied {
+		b.b.WriteString(m.text)
+	}
+}
+
+// Mark returns a checkpoint of the current state of the scanner. Calling
+// Reset with the mark restores This, Next, Pos, Val, Lit, Type, Errs, the
+// mode stack, and any trivia kept to the values they had when the mark was
+// created, even if tokens have been emitted or discarded since then.
+//
+// A mark remains valid while a RuleSet is evaluating rules for the next
+// token. When the scanner is used directly, a mark remains valid until the
+// next token is emitted.
+func (s *Scanner) Mark() Mark {
+	return Mark{
+		gen:     s.gen,
+		idx:     len(s.hist),
+		pos:     s.Pos,
+		tokPos:  s.tokPos,
+		tokIdx:  s.tokIdx,
+		val:     s.Val.mark(),
+		lit:     s.Lit.mark(),
+		type_:   s.Type,
+		errs:    slices.Clip(s.Errs),
+		modes:   slices.Clone(s.modes),
+		trivia:  slices.Clip(s.trivia),
+		suspend: s.suspend,
+	}
+}
+
+// Reset restores the scanner to the state found in mark m.
+func (s *Scanner) Reset(m Mark) {
+	if m.gen != s.gen {
+		s.halt(Error{
+			Pos:   s.Pos,
+			Cause: ErrInvalidMark,
+		})
+		return
+	}
+	s.rewind(m.pos, m.idx)
+	s.tokPos = m.tokPos
+	s.tokIdx = m.tokIdx
+	s.Val.reset(m.val)
+	s.Lit.reset(m.lit)
+	s.Type = m.type_
+	s.Errs = m.errs
+	s.modes = append(s.modes[:0], m.modes...)
+	s.trivia = m.trivia
+	s.suspend = m.suspend
+}
+
+// rewind moves the scanner back to position pos. When reading from memory,
+// the position offset is used directly. Otherwise, idx is the length that
+// the history of consumed runes had at that position and those runes are
+// placed back onto the input stream.
+func (s *Scanner) rewind(pos Pos, idx int) {
+	if s.src == nil {
+		s.Pos = pos
+		s.fill()
+		return
+	}
+
+	// If the history is shorter than the index, an Undo has already placed
+	// these runes back on the stream. Read them again.
+	for len(s.hist) < idx && s.This != EndOfText {
+		s.next()
+	}
+	if len(s.hist) > idx {
+		chs := s.hist[idx:]
+		if s.This != EndOfText {
+			chs = append(chs, held(s.This, s.thisLen))
+		}
+		if s.Next != EndOfText {
+			chs = append(chs, held(s.Next, s.nextLen))
+		}
+		err := s.src.UnreadAll(chs)
+		s.hist = s.hist[:idx]
+		if err != nil {
+			s.Pos = pos
+			s.halt(Error{
+				Pos:     pos,
+				Message: "unable to rewind",
+				Cause:   err,
+			})
+			return
+		}
+		s.Pos = pos
+		s.fill()
+		return
+	}
+	s.Pos = pos
+}
+
+// release discards the history of consumed runes and invalidates any
+// outstanding marks.
+func (s *Scanner) release() {
+	s.hist = s.hist[:0]
+	s.tokIdx = 0
+	s.gen++
+}
+
+// holdMarks keeps marks valid when tokens are emitted until a matching call
+// to releaseMarks.
+func (s *Scanner) holdMarks() {
+	s.hold++
+}
+
+func (s *Scanner) releaseMarks() {
+	s.hold--
+	// Only release at a token boundary so that an Undo of a token in
+	// progress still has its history.
+	if s.hold == 0 && s.Pos == s.tokPos {
+		s.release()
+	}
+}
@@ -0,0 +1,115 @@
+package scan
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func newTestScanners(src string) map[string]*Scanner {
+	return map[string]*Scanner{
+		"string": NewScannerFromString("", src),
+		"reader": NewScanner("", strings.NewReader(src)),
+	}
+}
+
+func TestMarkReset(t *testing.T) {
+	for name, s := range newTestScanners("ab\ncd\nef") {
+		t.Run(name, func(t *testing.T) {
+			s.Keep()
+			s.Illegal("x")
+			s.Type = "t"
+			m := s.Mark()
+			Repeat(s.Keep, 3)
+			s.Skip()
+			s.Illegal("y")
+			s.Type = "u"
+			s.Val.WriteString("!")
+			s.Reset(m)
+
+			if s.This != 'b' || s.Next != '\n' {
+				t.Errorf("\n have: %c %c \n want: b {!ch:\\n}", s.This, s.Next)
+			}
+			wantPos := Pos{Line: 1, Col: 2, Offset: 1}
+			if s.Pos != wantPos {
+				t.Errorf("\n have: %v \n want: %v", s.Pos, wantPos)
+			}
+			if s.Val.String() != "a" || s.Lit.String() != "a" {
+				t.Errorf("\n have: %v %v \n want: a a", s.Val.String(), s.Lit.String())
+			}
+			if s.Type != "t" || len(s.Errs) != 1 {
+				t.Errorf("\n have: %v %v \n want: t 1", s.Type, len(s.Errs))
+			}
+
+			While(s, IsAny, s.Keep)
+			tok := s.Emit()
+			if tok.Val != "ab\ncd\nef" {
+				t.Errorf("\n have: %v \n want: %v", Quote(tok.Val), Quote("ab\ncd\nef"))
+			}
+			wantEnd := Pos{Line: 3, Col: 3, Offset: 8}
+			if tok.End != wantEnd {
+				t.Errorf("\n have: %v \n want: %v", tok.End, wantEnd)
+			}
+		})
+	}
+}
+
+func TestMarkAcrossEmit(t *testing.T) {
+	for name, s := range newTestScanners("  abc") {
+		t.Run(name, func(t *testing.T) {
+			s.holdMarks()
+			defer s.releaseMarks()
+			m := s.Mark()
+			Space(s)
+			s.Keep()
+			s.Reset(m)
+			While(s, IsAny, s.Keep)
+			tok := s.Emit()
+			if tok.Val != "  abc" {
+				t.Errorf("\n have: %v \n want: %v", Quote(tok.Val), Quote("  abc"))
+			}
+		})
+	}
+}
+
+func TestMarkAfterUndo(t *testing.T) {
+	for name, s := range newTestScanners("abcd") {
+		t.Run(name, func(t *testing.T) {
+			s.Keep()
+			s.Keep()
+			m := s.Mark()
+			s.Keep()
+			s.Undo()
+			if s.This != 'a' {
+				t.Fatalf("\n have: %c \n want: a", s.This)
+			}
+			s.Reset(m)
+			if s.This != 'c' || s.Val.String() != "ab" {
+				t.Fatalf("\n have: %c %v \n want: c ab", s.This, s.Val.String())
+			}
+			While(s, IsAny, s.Keep)
+			if tok := s.Emit(); tok.Val != "abcd" {
+				t.Errorf("\n have: %v \n want: abcd", tok.Val)
+			}
+		})
+	}
+}
+
+func TestMarkStale(t *testing.T) {
+	s := NewScannerFromString("", "abc")
+	m := s.Mark()
+	s.Keep()
+	s.Emit()
+	s.Reset(m)
+
+	if s.HasMore() {
+		t.Fatalf("expected scanner to stop")
+	}
+	tok := s.Emit()
+	if tok.Type != ErrorType || !errors.Is(tok.Errs[0], ErrInvalidMark) {
+		t.Errorf("\n have: %v \n want: %v", tok, ErrInvalidMark)
+	}
+	if tok := s.Emit(); !tok.IsEndOfText() {
+		t.Errorf("\n have: %v \n want: end of text", tok)
+	}
+}
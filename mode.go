@@ -0,0 +1,84 @@
+package scan
+
+// Mode returns the name of the mode on top of the mode stack or an empty
+// string if the stack is empty.
+func (s *Scanner) Mode() string {
+	if len(s.modes) == 0 {
+		return ""
+	}
+	return s.modes[len(s.modes)-1]
+}
+
+// PushMode places the named mode on top of the mode stack. The rule set
+// registered with that name is used to scan tokens until it is popped.
+func (s *Scanner) PushMode(name string) {
+	s.modes = append(s.modes, name)
+}
+
+// PopMode removes the mode on top of the mode stack. Popping an empty stack
+// has no effect.
+func (s *Scanner) PopMode() {
+	if len(s.modes) > 0 {
+		s.modes = s.modes[:len(s.modes)-1]
+	}
+}
+
+// ModeRule evaluates a rule and, when it matches, pushes or pops a mode on
+// the scanner's mode stack.
+type ModeRule struct {
+	rule Rule
+	push string
+	pop  bool
+}
+
+func NewModeRule(rule Rule) ModeRule {
+	return ModeRule{rule: rule}
+}
+
+func (r ModeRule) WithPush(name string) ModeRule {
+	r.push = name
+	r.pop = false
+	return r
+}
+
+func (r ModeRule) WithPop(b bool) ModeRule {
+	r.pop = b
+	if b {
+		r.push = ""
+	}
+	return r
+}
+
+// Eval pushes or pops the mode when the rule matches. If the match was
+// suspended at the end of a line, the mode is changed once the match is
+// complete.
+func (r ModeRule) Eval(s *Scanner) bool {
+	if !r.rule.Eval(s) {
+		return false
+	}
+	if s.suspend == nil {
+		r.apply(s)
+	}
+	return true
+}
+
+// Resume continues the match of rule if it is a Resumer.
+func (r ModeRule) Resume(s *Scanner, data string) bool {
+	rr, ok := r.rule.(Resumer)
+	if !ok || !rr.Resume(s, data) {
+		return false
+	}
+	if s.suspend == nil {
+		r.apply(s)
+	}
+	return true
+}
+
+func (r ModeRule) apply(s *Scanner) {
+	switch {
+	case r.pop:
+		s.PopMode()
+	case r.push != "":
+		s.PushMode(r.push)
+	}
+}
@@ -0,0 +1,54 @@
+package scan
+
+import "testing"
+
+func TestModes(t *testing.T) {
+	tick := Literal("`")
+	rules := NewRuleSet(
+		SkipSpaceRule,
+		StandardIdentRule,
+		NewModeRule(tick).WithPush("template"),
+	).WithMode("template", NewRuleSet(
+		NewModeRule(tick).WithPop(true),
+		NewModeRule(Literal("${")).WithPush("expr"),
+		NewWhileRule(Not(Rune('`', '$')), StrType),
+	)).WithMode("expr", NewRuleSet(
+		SkipSpaceRule,
+		StandardIdentRule,
+		NewModeRule(tick).WithPush("template"),
+		NewModeRule(Literal("}")).WithPop(true),
+	))
+
+	tests := []Test{
+		NewTest("x `a ${b} c` y", "x", 1, 1, IdentType).
+			And("`", 1, 3, "`").
+			And("a ", 1, 4, StrType).
+			And("${", 1, 6, "${").
+			And("b", 1, 8, IdentType).
+			And("}", 1, 9, "}").
+			And(" c", 1, 10, StrType).
+			And("`", 1, 12, "`").
+			And("y", 1, 14, IdentType),
+		NewTest("`${`x`}`", "`", 1, 1, "`").
+			And("${", 1, 2, "${").
+			And("`", 1, 4, "`").
+			And("x", 1, 5, StrType).
+			And("`", 1, 6, "`").
+			And("}", 1, 7, "}").
+			And("`", 1, 8, "`"),
+	}
+	RunTests(t, rules, tests)
+}
+
+func TestUnknownMode(t *testing.T) {
+	rules := NewRuleSet(
+		NewModeRule(Literal("<")).WithPush("missing"),
+	)
+	tests := []Test{
+		NewTest("<<", "<", 1, 1, "<").
+			And("", 1, 2, ErrorType).
+			WithError(`1:2: error: unknown mode: "missing"`).
+			And("<", 1, 2, "<"),
+	}
+	RunTests(t, rules, tests)
+}
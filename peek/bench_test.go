@@ -0,0 +1,120 @@
+package peek
+
+import (
+	"bufio"
+	"io"
+	"strings"
+	"testing"
+)
+
+// sliceReader is the previous design of Reader which holds lookahead in a
+// slice that is reallocated when runes are pushed back. It is kept here to
+// compare performance against the ring buffer.
+type sliceReader struct {
+	src   *bufio.Reader
+	ahead []rune
+}
+
+func newSliceReader(r io.Reader) *sliceReader {
+	return &sliceReader{src: bufio.NewReader(r)}
+}
+
+func (r *sliceReader) Read() (rune, error) {
+	if len(r.ahead) > 0 {
+		var ch rune
+		ch, r.ahead = r.ahead[0], r.ahead[1:]
+		return ch, nil
+	}
+	ch, _, err := r.src.ReadRune()
+	return ch, err
+}
+
+func (r *sliceReader) Unread(ch rune) error {
+	return r.UnreadAll([]rune{ch})
+}
+
+func (r *sliceReader) UnreadAll(chs []rune) error {
+	r.ahead = append(chs, r.ahead...)
+	return nil
+}
+
+func (r *sliceReader) Peek(n int) (rune, error) {
+	for len(r.ahead) < n {
+		ch, _, err := r.src.ReadRune()
+		if err == io.EOF {
+			return EndOfText, nil
+		}
+		if err != nil {
+			return EndOfText, err
+		}
+		r.ahead = append(r.ahead, ch)
+	}
+	return r.ahead[n-1], nil
+}
+
+type runeReader interface {
+	Read() (rune, error)
+	Unread(rune) error
+	Peek(int) (rune, error)
+}
+
+var benchText = strings.Repeat("func main() { fmt.Println(\"hello, 世界\") }\n", 1000)
+
+func benchRead(b *testing.B, newReader func(io.Reader) runeReader) {
+	for i := 0; i < b.N; i++ {
+		r := newReader(strings.NewReader(benchText))
+		for {
+			if _, err := r.Read(); err != nil {
+				break
+			}
+		}
+	}
+}
+
+// benchBacktrack reads a few runes, pushes all but the first back one at a
+// time, and then repeats. This is the access pattern of a scanner rule that
+// speculates and then calls Undo.
+func benchBacktrack(b *testing.B, newReader func(io.Reader) runeReader) {
+	chs := make([]rune, 0, 8)
+	for i := 0; i < b.N; i++ {
+		r := newReader(strings.NewReader(benchText))
+		for {
+			chs = chs[:0]
+			for j := 0; j < 8; j++ {
+				ch, err := r.Read()
+				if err != nil {
+					break
+				}
+				chs = append(chs, ch)
+			}
+			if len(chs) == 0 {
+				break
+			}
+			for j := len(chs) - 1; j > 0; j-- {
+				r.Unread(chs[j])
+			}
+		}
+	}
+}
+
+func benchPeek(b *testing.B, newReader func(io.Reader) runeReader) {
+	for i := 0; i < b.N; i++ {
+		r := newReader(strings.NewReader(benchText))
+		for {
+			r.Peek(4)
+			if _, err := r.Read(); err != nil {
+				break
+			}
+		}
+	}
+}
+
+func newRing(r io.Reader) runeReader  { return NewReader(r) }
+func newSlice(r io.Reader) runeReader { return newSliceReader(r) }
+
+func BenchmarkRingRead(b *testing.B)       { benchRead(b, newRing) }
+func BenchmarkSliceRead(b *testing.B)      { benchRead(b, newSlice) }
+func BenchmarkRingBacktrack(b *testing.B)  { benchBacktrack(b, newRing) }
+func BenchmarkSliceBacktrack(b *testing.B) { benchBacktrack(b, newSlice) }
+func BenchmarkRingPeek(b *testing.B)       { benchPeek(b, newRing) }
+func BenchmarkSlicePeek(b *testing.B)      { benchPeek(b, newSlice) }
@@ -2,71 +2,204 @@ package peek
 
 import (
 	"bufio"
+	"errors"
 	"fmt"
 	"io"
+	"strings"
+	"unicode/utf8"
 )
 
 const EndOfText = rune(-1)
 
+// Invalid stands in for utf8.RuneError when it was decoded from a byte that
+// is not part of a valid UTF-8 encoding. Use it when pushing such a byte
+// back with Unread or UnreadAll. It is read again as utf8.RuneError with a
+// size of one byte.
+const Invalid = rune(-2)
+
+// ErrLookahead is returned when a peek or unread operation would hold more
+// runes than the maximum lookahead configured for the reader.
+var ErrLookahead = errors.New("maximum lookahead exceeded")
+
+const minBufLen = 16
+
+// Reader reads runes from a source and allows for runes to be peeked at and
+// pushed back onto the stream. Runes that have been peeked or unread are held
+// in a ring buffer which grows as needed up to the maximum lookahead.
 type Reader struct {
-	src   *bufio.Reader
-	ahead []rune
+	src  *bufio.Reader
+	buf  []rune
+	head int
+	n    int
+	max  int
 }
 
+// NewReader returns a reader for r with an unbounded lookahead.
 func NewReader(r io.Reader) *Reader {
-	return &Reader{src: bufio.NewReader(r)}
+	return NewReaderSize(r, 0)
+}
+
+// NewReaderSize returns a reader for r that can hold at most max runes of
+// lookahead. If max is zero or less, the lookahead is unbounded.
+func NewReaderSize(r io.Reader, max int) *Reader {
+	return &Reader{src: bufio.NewReader(r), max: max}
+}
+
+// Reset discards any buffered runes and switches the reader to read from r.
+// The lookahead buffer is retained for reuse.
+func (r *Reader) Reset(src io.Reader) {
+	r.src.Reset(src)
+	r.head = 0
+	r.n = 0
+}
+
+// Buffered returns the number of runes held in the lookahead buffer.
+func (r *Reader) Buffered() int {
+	return r.n
 }
 
 func (r *Reader) Read() (rune, error) {
-	if len(r.ahead) > 0 {
-		var ch rune
-		ch, r.ahead = r.ahead[0], r.ahead[1:]
-		return ch, nil
-	}
-	ch, _, err := r.src.ReadRune()
+	ch, _, err := r.ReadRune()
 	return ch, err
 }
 
-func (r *Reader) Unread(ch rune) {
-	r.UnreadAll([]rune{ch})
+// ReadRune reads the next rune and returns its size in bytes. A byte that
+// is not part of a valid UTF-8 encoding is returned as utf8.RuneError with
+// a size of one.
+func (r *Reader) ReadRune() (rune, int, error) {
+	if r.n > 0 {
+		ch := r.buf[r.head]
+		r.head = (r.head + 1) & (len(r.buf) - 1)
+		r.n--
+		if ch == Invalid {
+			return utf8.RuneError, 1, nil
+		}
+		return ch, utf8.RuneLen(ch), nil
+	}
+	return r.src.ReadRune()
 }
 
-func (r *Reader) UnreadAll(chs []rune) {
-	r.ahead = append(chs, r.ahead...)
+func (r *Reader) Unread(ch rune) error {
+	if err := r.reserve(1); err != nil {
+		return err
+	}
+	r.head = (r.head - 1) & (len(r.buf) - 1)
+	r.buf[r.head] = ch
+	r.n++
+	return nil
+}
+
+// UnreadAll pushes chs back onto the stream so that the next read returns
+// chs[0]. If the runes do not fit within the maximum lookahead, none are
+// pushed back and ErrLookahead is returned.
+func (r *Reader) UnreadAll(chs []rune) error {
+	if err := r.reserve(len(chs)); err != nil {
+		return err
+	}
+	mask := len(r.buf) - 1
+	for i := len(chs) - 1; i >= 0; i-- {
+		r.head = (r.head - 1) & mask
+		r.buf[r.head] = chs[i]
+	}
+	r.n += len(chs)
+	return nil
 }
 
 func (r *Reader) PeekTo(n int) (string, error) {
 	if n < 0 {
 		return "", fmt.Errorf("invalid peek value: %v", n)
 	}
-	if n <= len(r.ahead) {
-		return string(r.ahead[:n]), nil
+	err := r.fill(n)
+	var b strings.Builder
+	for i := 0; i < min(n, r.n); i++ {
+		b.WriteRune(decoded(r.at(i)))
 	}
+	return b.String(), err
+}
 
-	var ch rune
-	var err error
+func (r *Reader) Peek(n int) (rune, error) {
+	ch, _, err := r.PeekRune(n)
+	return ch, err
+}
 
-	diff := n - len(r.ahead)
-	for i := 0; i < diff; i++ {
-		ch, _, err = r.src.ReadRune()
+// PeekRune is the same as Peek but also returns the size of the rune in
+// bytes.
+func (r *Reader) PeekRune(n int) (rune, int, error) {
+	if n < 1 {
+		return EndOfText, 0, fmt.Errorf("invalid peek value: %v", n)
+	}
+	if err := r.fill(n); err != nil {
+		return EndOfText, 0, err
+	}
+	if n > r.n {
+		return EndOfText, 0, nil
+	}
+	ch := r.at(n - 1)
+	if ch == Invalid {
+		return utf8.RuneError, 1, nil
+	}
+	return ch, utf8.RuneLen(ch), nil
+}
+
+func (r *Reader) at(i int) rune {
+	return r.buf[(r.head+i)&(len(r.buf)-1)]
+}
+
+func decoded(ch rune) rune {
+	if ch == Invalid {
+		return utf8.RuneError
+	}
+	return ch
+}
+
+// fill reads from the source until there are at least n runes in the buffer
+// or the end of the stream has been reached.
+func (r *Reader) fill(n int) error {
+	if n <= r.n {
+		return nil
+	}
+	if r.max > 0 && n > r.max {
+		return ErrLookahead
+	}
+	if err := r.reserve(n - r.n); err != nil {
+		return err
+	}
+	mask := len(r.buf) - 1
+	for r.n < n {
+		ch, size, err := r.src.ReadRune()
+		if err == io.EOF {
+			return nil
+		}
 		if err != nil {
-			break
+			return err
 		}
-		r.ahead = append(r.ahead, ch)
-	}
-	if err == io.EOF {
-		err = nil
+		if ch == utf8.RuneError && size == 1 {
+			ch = Invalid
+		}
+		r.buf[(r.head+r.n)&mask] = ch
+		r.n++
 	}
-	return string(r.ahead), err
+	return nil
 }
 
-func (r *Reader) Peek(n int) (rune, error) {
-	_, err := r.PeekTo(n)
-	if err != nil {
-		return EndOfText, err
+// reserve ensures that there is room for n more runes in the buffer.
+func (r *Reader) reserve(n int) error {
+	need := r.n + n
+	if r.max > 0 && need > r.max {
+		return ErrLookahead
+	}
+	if need <= len(r.buf) {
+		return nil
+	}
+	size := max(len(r.buf), minBufLen)
+	for size < need {
+		size *= 2
 	}
-	if n > len(r.ahead) {
-		return EndOfText, nil
+	buf := make([]rune, size)
+	for i := 0; i < r.n; i++ {
+		buf[i] = r.at(i)
 	}
-	return r.ahead[n-1], nil
+	r.buf = buf
+	r.head = 0
+	return nil
 }
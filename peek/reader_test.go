@@ -6,6 +6,7 @@ import (
 	"strconv"
 	"strings"
 	"testing"
+	"unicode/utf8"
 
 	"github.com/blackchip-org/scan/iotest"
 )
@@ -128,6 +129,49 @@ func TestUnreadAll(t *testing.T) {
 	}
 }
 
+func TestReadRuneSize(t *testing.T) {
+	r := NewReader(strings.NewReader("a\uFFFD\xffb"))
+	want := []struct {
+		ch   rune
+		size int
+	}{
+		{'a', 1},
+		{utf8.RuneError, 3},
+		{utf8.RuneError, 1},
+		{'b', 1},
+	}
+	for i, w := range want {
+		ch, size, err := r.PeekRune(i + 1)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ch != w.ch || size != w.size {
+			t.Errorf("peek %v\n have: %q %v \n want: %q %v", i, ch, size, w.ch, w.size)
+		}
+	}
+	var chs []rune
+	for range want {
+		ch, _, err := r.ReadRune()
+		if err != nil {
+			t.Fatal(err)
+		}
+		chs = append(chs, ch)
+	}
+	chs[2] = Invalid
+	if err := r.UnreadAll(chs); err != nil {
+		t.Fatal(err)
+	}
+	for i, w := range want {
+		ch, size, err := r.ReadRune()
+		if err != nil {
+			t.Fatal(err)
+		}
+		if ch != w.ch || size != w.size {
+			t.Errorf("read %v\n have: %q %v \n want: %q %v", i, ch, size, w.ch, w.size)
+		}
+	}
+}
+
 func TestReadError(t *testing.T) {
 	text := "123456"
 	sr := iotest.NewReader(strings.NewReader(text))
@@ -173,3 +217,63 @@ func TestReadError(t *testing.T) {
 		t.Fatal(err)
 	}
 }
+
+func TestUnreadWrap(t *testing.T) {
+	r := NewReader(strings.NewReader("abcdefghijklmnopqrstuvwxyz"))
+	var have []rune
+	for {
+		// Read three runes and push the last two back so that the head of
+		// the buffer keeps moving around the ring.
+		var chs []rune
+		for i := 0; i < 3; i++ {
+			ch, err := r.Read()
+			if err != nil {
+				break
+			}
+			chs = append(chs, ch)
+		}
+		if len(chs) == 0 {
+			break
+		}
+		have = append(have, chs[0])
+		if len(chs) > 1 {
+			if err := r.UnreadAll(chs[1:]); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if _, err := r.Peek(4); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want := "abcdefghijklmnopqrstuvwxyz"
+	if string(have) != want {
+		t.Errorf("\n have: %v \n want: %v", string(have), want)
+	}
+}
+
+func TestMaxLookahead(t *testing.T) {
+	r := NewReaderSize(strings.NewReader("123456"), 3)
+	if _, err := r.Peek(3); err != nil {
+		t.Fatal(err)
+	}
+	if _, err := r.Peek(4); !errors.Is(err, ErrLookahead) {
+		t.Fatalf("\n have: %v \n want: %v", err, ErrLookahead)
+	}
+	if err := r.Unread('X'); !errors.Is(err, ErrLookahead) {
+		t.Fatalf("\n have: %v \n want: %v", err, ErrLookahead)
+	}
+	ch, _ := r.Read()
+	if ch != '1' {
+		t.Fatalf("\n have: %c \n want: 1", ch)
+	}
+	if err := r.UnreadAll([]rune("XY")); !errors.Is(err, ErrLookahead) {
+		t.Fatalf("\n have: %v \n want: %v", err, ErrLookahead)
+	}
+	if err := r.Unread('X'); err != nil {
+		t.Fatal(err)
+	}
+	v, _ := r.PeekTo(3)
+	if v != "X23" {
+		t.Errorf("\n have: %v \n want: X23", v)
+	}
+}
@@ -0,0 +1,82 @@
+package scan
+
+import "io"
+
+// Printer writes tokens back out as source text. The literal of a token is
+// written when present. Otherwise, the value is encoded with Encode.
+//
+// The leading and trailing trivia of each token are written as well. When
+// the tokens were scanned with WithTrivia, printing them reproduces the
+// original source.
+type Printer struct {
+	// Sep is written between two tokens when there is no trivia between
+	// them and they were not next to each other in the source, such as when
+	// whitespace was discarded or when a token was inserted after scanning.
+	Sep string
+
+	// Encode returns the source text for a token that does not have a
+	// literal, such as a token that was created or changed after scanning.
+	// If nil, the value of the token is used.
+	Encode func(Token) string
+}
+
+// Print writes the source text for toks to w. The end of text token may be
+// included to write any trivia found before it.
+func (p Printer) Print(w io.Writer, toks []Token) error {
+	pw := printWriter{w: w}
+	for i, tok := range toks {
+		if i > 0 && p.needsSep(toks[i-1], tok) {
+			pw.write(p.Sep)
+		}
+		for _, t := range tok.Leading {
+			pw.write(p.text(t))
+		}
+		if !tok.IsEndOfText() {
+			pw.write(p.text(tok))
+		}
+		for _, t := range tok.Trailing {
+			pw.write(p.text(t))
+		}
+	}
+	return pw.err
+}
+
+func (p Printer) needsSep(prev Token, tok Token) bool {
+	if p.Sep == "" || tok.IsEndOfText() {
+		return false
+	}
+	if len(prev.Trailing) > 0 || len(tok.Leading) > 0 {
+		return false
+	}
+	adjacent := tok.Pos.Line > 0 && prev.End == tok.Pos
+	return !adjacent
+}
+
+func (p Printer) text(t Token) string {
+	if t.Lit != "" {
+		return t.Lit
+	}
+	if p.Encode != nil {
+		return p.Encode(t)
+	}
+	return t.Val
+}
+
+// Print writes the source text for toks to w using a Printer with the
+// default configuration.
+func Print(w io.Writer, toks []Token) error {
+	return Printer{}.Print(w, toks)
+}
+
+// printWriter keeps the first error seen and ignores writes after that.
+type printWriter struct {
+	w   io.Writer
+	err error
+}
+
+func (pw *printWriter) write(s string) {
+	if pw.err != nil || s == "" {
+		return
+	}
+	_, pw.err = io.WriteString(pw.w, s)
+}
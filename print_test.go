@@ -0,0 +1,74 @@
+package scan
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func TestPrintSep(t *testing.T) {
+	rules := NewRuleSet(
+		SkipSpaceRule,
+		NewCommentRule(Literal("//"), Literal("\n")),
+		StandardIdentRule,
+		Literal("(", ")", "+"),
+	)
+	src := "f(a  +b)  // c\n"
+	tests := []struct {
+		trivia bool
+		want   string
+	}{
+		{false, "f(a +b)"},
+		{true, src},
+	}
+	for _, test := range tests {
+		s := NewScannerFromString("", src)
+		r := NewRunner(s, rules, WithTrivia(test.trivia))
+		var b strings.Builder
+		if err := (Printer{Sep: " "}).Print(&b, append(r.All(), r.This)); err != nil {
+			t.Fatal(err)
+		}
+		if have := b.String(); have != test.want {
+			t.Errorf("\n have: %q \n want: %q", have, test.want)
+		}
+	}
+}
+
+func TestPrintEncode(t *testing.T) {
+	toks := []Token{
+		{Val: "a", Lit: "a", Type: IdentType},
+		{Val: "=", Type: "="},
+		{Val: "x\ny", Type: StrType},
+	}
+	p := Printer{
+		Sep: " ",
+		Encode: func(t Token) string {
+			if t.Type == StrType {
+				return Quote(t.Val)
+			}
+			return t.Val
+		},
+	}
+	var b strings.Builder
+	if err := p.Print(&b, toks); err != nil {
+		t.Fatal(err)
+	}
+	want := `a = "x{!ch:\n}y"`
+	if have := b.String(); have != want {
+		t.Errorf("\n have: %v \n want: %v", have, want)
+	}
+}
+
+type errWriter struct{ err error }
+
+func (w errWriter) Write(p []byte) (int, error) {
+	return 0, w.err
+}
+
+func TestPrintError(t *testing.T) {
+	want := errors.New("test error")
+	err := Print(errWriter{want}, []Token{{Val: "a"}, {Val: "b"}})
+	if !errors.Is(err, want) {
+		t.Errorf("\n have: %v \n want: %v", err, want)
+	}
+}
@@ -0,0 +1,93 @@
+package scan
+
+import (
+	"fmt"
+	"io"
+	"regexp"
+)
+
+// RegexRule matches a regular expression at the current position.
+type RegexRule struct {
+	re      *regexp.Regexp
+	pattern string
+	type_   string
+	prefix  string
+	err     error
+}
+
+// NewRegexRule returns a rule that matches the regular expression pattern
+// at the current position and sets the token type to type_. The pattern
+// uses the syntax of the regexp package and is anchored so that it must
+// match starting at the current rune. When more than one match is possible,
+// the longest is used. A match of the empty string is not a match.
+//
+// The input is read using Peek so the rule works with readers as well as
+// strings. A pattern that does not compile is reported by Validate.
+func NewRegexRule(pattern string, type_ string) RegexRule {
+	r := RegexRule{pattern: pattern, type_: type_}
+	re, err := regexp.Compile(`^(?:` + pattern + `)`)
+	if err != nil {
+		r.err = fmt.Errorf("%w: %v", ErrInvalidRule, err)
+		return r
+	}
+	re.Longest()
+	r.re = re
+	// The literal prefix is only found when the pattern is not anchored
+	r.prefix, _ = regexp.MustCompile(pattern).LiteralPrefix()
+	return r
+}
+
+func (r RegexRule) Validate() error {
+	return r.err
+}
+
+func (r RegexRule) Eval(s *Scanner) bool {
+	if r.err != nil {
+		s.InvalidRule(r.err)
+		return true
+	}
+	if s.This == EndOfText {
+		return false
+	}
+	pr := &peekReader{s: s}
+	loc := r.re.FindReaderIndex(pr)
+	if loc == nil || loc[1] == 0 {
+		return false
+	}
+	for n := 0; n < loc[1]; {
+		n += pr.sizes[0]
+		pr.sizes = pr.sizes[1:]
+		s.Keep()
+	}
+	s.Type = r.type_
+	return true
+}
+
+// peekReader reads runes from the scanner without consuming them. The size
+// of each rune read is saved so that the byte offsets of a match can be
+// converted back to a number of runes.
+type peekReader struct {
+	s     *Scanner
+	i     int
+	off   int
+	sizes []int
+}
+
+func (p *peekReader) ReadRune() (rune, int, error) {
+	var ch rune
+	var size int
+	if p.s.src == nil {
+		// Read from memory directly as Peek starts over from the current
+		// position each time
+		ch, size = p.s.read(p.s.Pos.Offset + p.off)
+		p.off += size
+	} else {
+		ch, size = p.s.peekRune(p.i)
+		p.i++
+	}
+	if ch == EndOfText {
+		return 0, 0, io.EOF
+	}
+	p.sizes = append(p.sizes, size)
+	return ch, size, nil
+}
@@ -0,0 +1,70 @@
+package scan
+
+import (
+	"errors"
+	"strings"
+	"testing"
+)
+
+func regexRules() RuleSet {
+	return NewRuleSet(
+		SkipSpaceRule,
+		NewRegexRule(`\d{4}-\d{2}-\d{2}T\d{2}:\d{2}:\d{2}Z`, "time"),
+		NewRegexRule(`[0-9a-f]{8}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{4}-[0-9a-f]{12}`, "uuid"),
+		NewRegexRule(`v\d+(\.\d+)*`, "version"),
+		NewRegexRule(`a|ab|abc`, "abc"),
+		NewRegexRule(`x*`, "x"),
+		NewRegexRule(`é+`, "e"),
+		IntRule,
+		StandardIdentRule,
+	)
+}
+
+func TestRegex(t *testing.T) {
+	tests := []Test{
+		NewTest("2024-01-02T03:04:05Z 2024", "2024-01-02T03:04:05Z", 1, 1, "time").
+			And("2024", 1, 22, IntType),
+		NewTest("123e4567-e89b-12d3-a456-426614174000", "123e4567-e89b-12d3-a456-426614174000", 1, 1, "uuid"),
+		NewTest("v1.2.3 v1. vx", "v1.2.3", 1, 1, "version").
+			And("v1", 1, 8, "version").
+			And(".", 1, 10, IllegalType).WithError("1:10: error: unexpected \".\"").
+			And("vx", 1, 12, IdentType),
+		NewTest("abcd", "abc", 1, 1, "abc").
+			And("d", 1, 4, IdentType),
+		NewTest("xx y", "xx", 1, 1, "x").
+			And("y", 1, 4, IdentType),
+		NewTest("éé1", "éé", 1, 1, "e").
+			And("1", 1, 3, IntType),
+	}
+	RunTests(t, regexRules(), tests)
+}
+
+func TestRegexReader(t *testing.T) {
+	src := "v1.2.3 2024-01-02T03:04:05Z éé abcd"
+	want := NewRunner(NewScannerFromString("", src), regexRules()).All()
+	have := NewRunner(NewScanner("", strings.NewReader(src)), regexRules()).All()
+	if len(have) != len(want) {
+		t.Fatalf("\n have: %v \n want: %v", have, want)
+	}
+	for i := range have {
+		if have[i].Val != want[i].Val || have[i].Type != want[i].Type || have[i].Pos != want[i].Pos {
+			t.Errorf("\n have: %v \n want: %v", have[i], want[i])
+		}
+	}
+}
+
+func TestRegexInvalid(t *testing.T) {
+	rule := NewRegexRule(`a(`, "a")
+	if err := Validate(rule); !errors.Is(err, ErrInvalidRule) {
+		t.Errorf("\n have: %v \n want: %v", err, ErrInvalidRule)
+	}
+}
+
+func TestRegexStart(t *testing.T) {
+	if start := StartOf(NewRegexRule(`v\d+`, "v")); start == nil || !start('v') || start('w') {
+		t.Errorf("unexpected start class")
+	}
+	if start := StartOf(NewRegexRule(`[a-z]+`, "v")); start != nil {
+		t.Errorf("start class should not be known")
+	}
+}
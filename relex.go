@@ -0,0 +1,142 @@
+package scan
+
+import "slices"
+
+// Edit is a change made to source text. The number of bytes given by
+// Deleted, starting at Offset, are replaced with the Inserted text.
+type Edit struct {
+	Offset   int
+	Deleted  int
+	Inserted string
+}
+
+// Relex returns the tokens for src after edit has been applied to the
+// source that toks were scanned from. Only the part of the source affected
+// by the edit is scanned again. The scan starts at the last token boundary
+// before the edit and stops once the new tokens line up with the old tokens.
+// The remaining tokens are copied with their positions shifted.
+//
+// The rules and options should be the same as those used to scan toks. The
+// scan restarts in the default mode with an empty scanner state. The post
+// token function of the rules is then called with the token before the
+// restart point, and its result discarded, so that a function that keeps
+// the last token in the scanner state can restore it. Pre and post token
+// functions are not otherwise called for tokens that are not scanned again.
+func Relex(src string, toks []Token, edit Edit, rules RuleSet, opts ...RunnerOption) []Token {
+	var name string
+	if len(toks) > 0 {
+		name = toks[0].Pos.Name
+	}
+
+	// Restart one token before the token that contains the edit in case
+	// the edit changes how that token ends.
+	k := 0
+	for k < len(toks) && toks[k].Pos.Offset < edit.Offset {
+		k++
+	}
+	k = max(k-2, 0)
+	pos := NewPos(name)
+	if k > 0 {
+		pos = tokenStart(toks[k])
+	}
+
+	s := &Scanner{}
+	s.InitFromStringAt(pos, src)
+	if k > 0 && rules.postTokenFunc != nil {
+		rules.postTokenFunc(s, toks[k-1])
+	}
+
+	delta := len(edit.Inserted) - edit.Deleted
+	oldEnd := edit.Offset + edit.Deleted
+	newEnd := edit.Offset + len(edit.Inserted)
+
+	out := slices.Clone(toks[:k])
+	j := k
+	r := NewRunner(s, rules, opts...)
+	for tok := range r.Tokens() {
+		start := tokenStart(tok)
+		if start.Offset >= newEnd {
+			for j < len(toks) && tokenStart(toks[j]).Offset+delta < start.Offset {
+				j++
+			}
+			if j < len(toks) && tokenStart(toks[j]).Offset >= oldEnd {
+				sh := newShift(tokenStart(toks[j]), start, delta)
+				if sh.token(toks[j]).Equal(tok) {
+					for _, old := range toks[j:] {
+						out = append(out, sh.token(old))
+					}
+					return out
+				}
+			}
+		}
+		out = append(out, tok)
+	}
+	return out
+}
+
+// tokenStart returns the position of the first leading trivia of t or the
+// position of t if there is no trivia.
+func tokenStart(t Token) Pos {
+	if len(t.Leading) > 0 {
+		return t.Leading[0].Pos
+	}
+	return t.Pos
+}
+
+// shift moves positions found after an edit to where they are found in the
+// edited source. Positions found on the same line as the reference position
+// have their column adjusted.
+type shift struct {
+	ref    Pos
+	offset int
+	line   int
+	col    int
+}
+
+func newShift(from Pos, to Pos, delta int) shift {
+	return shift{
+		ref:    from,
+		offset: delta,
+		line:   to.Line - from.Line,
+		col:    to.Col - from.Col,
+	}
+}
+
+func (sh shift) pos(p Pos) Pos {
+	if p.Line == sh.ref.Line {
+		p.Col += sh.col
+	}
+	p.Line += sh.line
+	p.Offset += sh.offset
+	return p
+}
+
+func (sh shift) token(t Token) Token {
+	t.Pos = sh.pos(t.Pos)
+	t.End = sh.pos(t.End)
+	if len(t.Errs) > 0 {
+		errs := make(Errors, len(t.Errs))
+		for i, e := range t.Errs {
+			e.Pos = sh.pos(e.Pos)
+			if e.End != (Pos{}) {
+				e.End = sh.pos(e.End)
+			}
+			errs[i] = e
+		}
+		t.Errs = errs
+	}
+	t.Leading = sh.tokens(t.Leading)
+	t.Trailing = sh.tokens(t.Trailing)
+	return t
+}
+
+func (sh shift) tokens(ts []Token) []Token {
+	if len(ts) == 0 {
+		return ts
+	}
+	out := make([]Token, len(ts))
+	for i, t := range ts {
+		out[i] = sh.token(t)
+	}
+	return out
+}
@@ -0,0 +1,152 @@
+package scan
+
+import (
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func applyEdit(src string, e Edit) string {
+	return src[:e.Offset] + e.Inserted + src[e.Offset+e.Deleted:]
+}
+
+func TestRelex(t *testing.T) {
+	rules := NewRuleSet(
+		SkipSpaceRule,
+		NewCommentRule(Literal("/*"), Literal("*/")),
+		NewCommentRule(Literal("//"), Literal("\n")),
+		StandardIdentRule,
+		IntRule,
+		StrDoubleQuoteRule,
+		Literal("(", ")", "{", "}", "+", "=", ";"),
+	)
+	src := strings.Repeat("func f(a) {\n\tx = \"str\" + 42 // c\n\t/* d */ y = a\n}\n", 4)
+	inserts := []string{"", "a", "b1", " ", "\n", "\"", "/*", "*/", "//", "(", "9", "\t\n x"}
+
+	rnd := rand.New(rand.NewPCG(1, 2))
+	for _, trivia := range []bool{false, true} {
+		old := NewRunner(NewScannerFromString("test", src), rules, WithTrivia(trivia)).All()
+		for i := 0; i < 500; i++ {
+			e := Edit{Offset: rnd.IntN(len(src) + 1)}
+			e.Deleted = rnd.IntN(min(4, len(src)-e.Offset) + 1)
+			e.Inserted = inserts[rnd.IntN(len(inserts))]
+			edited := applyEdit(src, e)
+
+			want := NewRunner(NewScannerFromString("test", edited), rules, WithTrivia(trivia)).All()
+			have := Relex(edited, old, e, rules, WithTrivia(trivia))
+			if !slices.EqualFunc(have, want, Token.Equal) {
+				t.Fatalf("edit %+v (trivia %v):\n have: %v \n want: %v", e, trivia, have, want)
+			}
+		}
+	}
+}
+
+func TestRelexSemicolons(t *testing.T) {
+	rules := NewRuleSet(
+		NewWhileRule(Rune(' ', '\t'), SpaceType).WithKeep(false),
+		Literal("\n", "(", ")", "}"),
+		StandardIdentRule,
+	).WithPostTokenFunc(InsertSemicolons("\n", IdentType, ")", "}"))
+	src := strings.Repeat("f(a)\n\nx \n}\n(\n", 4)
+	inserts := []string{"", "a", "\n", ")", " \n"}
+
+	rnd := rand.New(rand.NewPCG(3, 4))
+	old := NewRunner(NewScannerFromString("", src), rules).All()
+	for i := 0; i < 200; i++ {
+		e := Edit{Offset: rnd.IntN(len(src) + 1)}
+		e.Deleted = rnd.IntN(min(3, len(src)-e.Offset) + 1)
+		e.Inserted = inserts[rnd.IntN(len(inserts))]
+		edited := applyEdit(src, e)
+
+		want := NewRunner(NewScannerFromString("", edited), rules).All()
+		have := Relex(edited, old, e, rules)
+		if !slices.EqualFunc(have, want, Token.Equal) {
+			t.Fatalf("edit %+v:\n have: %v \n want: %v", e, have, want)
+		}
+	}
+}
+
+type lastValKey struct{}
+
+func TestRelexState(t *testing.T) {
+	// An identifier after a dot is a field. The last token is kept in the
+	// scanner state as suggested for post token functions.
+	rules := NewRuleSet(SkipSpaceRule, StandardIdentRule, Literal(".")).
+		WithPostTokenFunc(func(s *Scanner, tok Token) Token {
+			last, _ := s.State(lastValKey{}).(*string)
+			if last == nil {
+				last = new(string)
+				s.SetState(lastValKey{}, last)
+			}
+			if *last == "." && tok.Type == IdentType {
+				tok.Type = "field"
+			}
+			*last = tok.Val
+			return tok
+		})
+	src := strings.Repeat("a.b c .d e\n", 4)
+	inserts := []string{"", "x", ".", " ", "\n"}
+
+	rnd := rand.New(rand.NewPCG(5, 6))
+	old := NewRunner(NewScannerFromString("", src), rules).All()
+	for i := 0; i < 200; i++ {
+		e := Edit{Offset: rnd.IntN(len(src) + 1)}
+		e.Deleted = rnd.IntN(min(3, len(src)-e.Offset) + 1)
+		e.Inserted = inserts[rnd.IntN(len(inserts))]
+		edited := applyEdit(src, e)
+
+		want := NewRunner(NewScannerFromString("", edited), rules).All()
+		have := Relex(edited, old, e, rules)
+		if !slices.EqualFunc(have, want, Token.Equal) {
+			t.Fatalf("edit %+v:\n have: %v \n want: %v", e, have, want)
+		}
+	}
+}
+
+func TestRelexErrorEnd(t *testing.T) {
+	tok := Token{
+		Pos:  Pos{Line: 2, Col: 3, Offset: 10},
+		End:  Pos{Line: 2, Col: 5, Offset: 12},
+		Errs: Errors{{Pos: Pos{Line: 2, Col: 3, Offset: 10}, End: Pos{Line: 2, Col: 5, Offset: 12}}},
+	}
+	sh := newShift(tok.Pos, Pos{Line: 3, Col: 1, Offset: 14}, 4)
+	have := sh.token(tok).Errs[0]
+	want := Error{Pos: Pos{Line: 3, Col: 1, Offset: 14}, End: Pos{Line: 3, Col: 3, Offset: 16}}
+	if !have.Equal(want) {
+		t.Errorf("\n have: %v-%v \n want: %v-%v", have.Pos, have.End, want.Pos, want.End)
+	}
+}
+
+func TestRelexResync(t *testing.T) {
+	var seen []Token
+	rules := NewRuleSet(SkipSpaceRule, StandardIdentRule).
+		WithPostTokenFunc(func(s *Scanner, tok Token) Token {
+			seen = append(seen, tok)
+			return tok
+		})
+	src := strings.Repeat("abc def\n", 1000)
+	old := NewRunner(NewScannerFromString("", src), rules).All()
+
+	e := Edit{Offset: 4000, Deleted: 3, Inserted: "xyz\nuvw"}
+	edited := applyEdit(src, e)
+	seen = nil
+	have := Relex(edited, old, e, rules)
+	if len(seen) > 20 {
+		t.Errorf("too many tokens scanned: %v", len(seen))
+	}
+	// The scan restarts one token before the token at the edit. The post
+	// token function is replayed for the token before that and is not
+	// called for any other token before the restart point.
+	restart := e.Offset - 8
+	if seen[0].Pos.Offset != restart-4 {
+		t.Errorf("post token function not replayed: %v", seen[0])
+	}
+	if seen[1].Pos.Offset < restart {
+		t.Errorf("post token function called for %v", seen[1])
+	}
+	want := NewRunner(NewScannerFromString("", edited), rules).All()
+	if !slices.EqualFunc(have, want, Token.Equal) {
+		t.Errorf("tokens differ")
+	}
+}
@@ -0,0 +1,187 @@
+package scan
+
+import (
+	"fmt"
+	"io"
+	"strconv"
+	"strings"
+	"unicode"
+	"unicode/utf8"
+)
+
+// Renderer writes errors in the style of the Rust compiler. Each error is
+// followed by the line of source where it was found with the text that
+// caused the error underlined:
+//
+//	error[str.unterminated]: not terminated
+//	 --> main.go:4:14
+//	  |
+//	4 |     x := "abc
+//	  |              ^
+//
+// Tabs are expanded and wide runes take up two columns so that the
+// underline lines up with the source in a terminal.
+type Renderer struct {
+	// TabWidth is the number of columns between tab stops. If zero, a
+	// width of 4 is used.
+	TabWidth int
+}
+
+// Render writes errs to w. The source text is used to show the line where
+// each error was found. If the source is empty or the position of an error
+// is not found in the source, only the message and position are written.
+func (r Renderer) Render(w io.Writer, src string, errs Errors) error {
+	pw := printWriter{w: w}
+	for i, e := range errs {
+		if i > 0 {
+			pw.write("\n")
+		}
+		r.render(&pw, src, e)
+	}
+	return pw.err
+}
+
+func (r Renderer) render(pw *printWriter, src string, e Error) {
+	header := e.Severity.String()
+	if e.Code != "" {
+		header += "[" + e.Code + "]"
+	}
+	pw.write(fmt.Sprintf("%v: %v\n", header, e.Text()))
+
+	lineNo := strconv.Itoa(e.Pos.Line)
+	pad := strings.Repeat(" ", len(lineNo))
+	pw.write(fmt.Sprintf("%v--> %v\n", pad, e.Pos))
+
+	off := e.Pos.Offset
+	if src == "" || off < 0 || off > len(src) {
+		return
+	}
+	start := strings.LastIndexByte(src[:off], '\n') + 1
+	end := strings.IndexByte(src[off:], '\n')
+	if end < 0 {
+		end = len(src)
+	} else {
+		end += off
+	}
+	line := strings.TrimSuffix(src[start:end], "\r")
+
+	// The underline covers the text up to End when it is on the same line
+	// and the rune at the position otherwise.
+	rel := min(off-start, len(line))
+	col := r.width(0, line[:rel])
+	n := 0
+	if e.End.Offset > off {
+		n = r.width(col, line[rel:min(e.End.Offset-start, len(line))])
+	} else if rel < len(line) {
+		_, size := utf8.DecodeRuneInString(line[rel:])
+		n = r.width(col, line[rel:rel+size])
+	}
+	n = max(n, 1)
+
+	pw.write(fmt.Sprintf("%v |\n", pad))
+	pw.write(fmt.Sprintf("%v | %v\n", lineNo, strings.TrimRight(r.expand(line), " ")))
+	pw.write(fmt.Sprintf("%v | %v%v\n", pad, strings.Repeat(" ", col), strings.Repeat("^", n)))
+}
+
+func (r Renderer) tabWidth() int {
+	if r.TabWidth <= 0 {
+		return 4
+	}
+	return r.TabWidth
+}
+
+// width returns the number of columns used to display text starting at
+// column col.
+func (r Renderer) width(col int, text string) int {
+	n := 0
+	for _, ch := range text {
+		if ch == '\t' {
+			tw := r.tabWidth()
+			n += tw - (col+n)%tw
+			continue
+		}
+		n += RuneWidth(ch)
+	}
+	return n
+}
+
+// expand replaces tabs in line with spaces up to the next tab stop.
+func (r Renderer) expand(line string) string {
+	if !strings.Contains(line, "\t") {
+		return line
+	}
+	var b strings.Builder
+	col := 0
+	for _, ch := range line {
+		if ch == '\t' {
+			n := r.width(col, "\t")
+			b.WriteString(strings.Repeat(" ", n))
+			col += n
+			continue
+		}
+		b.WriteRune(ch)
+		col += RuneWidth(ch)
+	}
+	return b.String()
+}
+
+// Render writes errs to w using a Renderer with the default settings.
+func Render(w io.Writer, src string, errs Errors) error {
+	return Renderer{}.Render(w, src, errs)
+}
+
+// RuneWidth returns the number of columns used to display ch in a
+// terminal. Combining marks and control characters have a width of zero
+// and wide East Asian characters and emoji have a width of two.
+func RuneWidth(ch rune) int {
+	switch {
+	case ch == 0:
+		return 0
+	case unicode.Is(unicode.Mn, ch), unicode.Is(unicode.Me, ch), unicode.Is(unicode.Cf, ch):
+		return 0
+	case unicode.IsControl(ch):
+		return 0
+	case isWide(ch):
+		return 2
+	}
+	return 1
+}
+
+// wideRanges are the ranges of runes that have an East Asian width of wide
+// or full width.
+var wideRanges = []struct{ lo, hi rune }{
+	{0x1100, 0x115f},
+	{0x231a, 0x231b},
+	{0x2329, 0x232a},
+	{0x23e9, 0x23ec},
+	{0x25fd, 0x25fe},
+	{0x2614, 0x2615},
+	{0x2e80, 0x303e},
+	{0x3041, 0x33ff},
+	{0x3400, 0x4dbf},
+	{0x4e00, 0x9fff},
+	{0xa000, 0xa4cf},
+	{0xa960, 0xa97f},
+	{0xac00, 0xd7a3},
+	{0xf900, 0xfaff},
+	{0xfe10, 0xfe19},
+	{0xfe30, 0xfe6f},
+	{0xff00, 0xff60},
+	{0xffe0, 0xffe6},
+	{0x1f300, 0x1f64f},
+	{0x1f900, 0x1f9ff},
+	{0x20000, 0x2fffd},
+	{0x30000, 0x3fffd},
+}
+
+func isWide(ch rune) bool {
+	for _, r := range wideRanges {
+		if ch < r.lo {
+			return false
+		}
+		if ch <= r.hi {
+			return true
+		}
+	}
+	return false
+}
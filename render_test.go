@@ -0,0 +1,127 @@
+package scan
+
+import (
+	"strings"
+	"testing"
+)
+
+func TestRender(t *testing.T) {
+	tests := []struct {
+		src  string
+		err  Error
+		want string
+	}{
+		{
+			"x := \"abc",
+			Error{Pos: Pos{Name: "a.go", Line: 1, Col: 10, Offset: 9}, Message: "not terminated", Code: "str.unterminated"},
+			"error[str.unterminated]: not terminated\n" +
+				" --> a.go:1:10\n" +
+				"  |\n" +
+				"1 | x := \"abc\n" +
+				"  |          ^\n",
+		},
+		{
+			"a\n\tb\tc = 世界 ?",
+			Error{Pos: Pos{Line: 2, Col: 9, Offset: 17}, Message: "unexpected"},
+			"error: unexpected\n" +
+				" --> 2:9\n" +
+				"  |\n" +
+				"2 |     b   c = 世界 ?\n" +
+				"  |                   ^\n",
+		},
+		{
+			"a = 世界 + b",
+			Error{Pos: Pos{Line: 1, Col: 5, Offset: 4}, End: Pos{Line: 1, Col: 7, Offset: 10}, Message: "bad", Severity: SeverityWarning},
+			"warning: bad\n" +
+				" --> 1:5\n" +
+				"  |\n" +
+				"1 | a = 世界 + b\n" +
+				"  |     ^^^^\n",
+		},
+		{
+			"",
+			Error{Pos: Pos{Line: 12, Col: 1}, Cause: ErrNotAdvancing, Severity: SeverityInfo},
+			"info: scanner is not advancing\n" +
+				"  --> 12:1\n",
+		},
+	}
+	for _, test := range tests {
+		var b strings.Builder
+		if err := Render(&b, test.src, Errors{test.err}); err != nil {
+			t.Fatal(err)
+		}
+		if b.String() != test.want {
+			t.Errorf("\n have:\n%v\n want:\n%v", b.String(), test.want)
+		}
+	}
+}
+
+func TestRenderScanned(t *testing.T) {
+	src := "abc \"def\n\"ghi"
+	rules := NewRuleSet(SkipSpaceRule, StandardIdentRule, StrDoubleQuoteRule)
+	var errs Errors
+	for tok := range Tokens("t", src, rules) {
+		errs = append(errs, tok.Errs...)
+	}
+	var b strings.Builder
+	Render(&b, src, errs)
+	want := "error[str.unterminated]: not terminated\n" +
+		" --> t:1:9\n" +
+		"  |\n" +
+		"1 | abc \"def\n" +
+		"  |         ^\n" +
+		"\n" +
+		"error[str.unterminated]: not terminated\n" +
+		" --> t:2:5\n" +
+		"  |\n" +
+		"2 | \"ghi\n" +
+		"  |     ^\n"
+	if b.String() != want {
+		t.Errorf("\n have:\n%v\n want:\n%v", b.String(), want)
+	}
+}
+
+func TestErrorSpans(t *testing.T) {
+	rules := NewRuleSet(
+		SkipSpaceRule,
+		NewCommentRule(Literal("/*"), Literal("*/")),
+		StrDoubleQuoteRule.WithEscapeRules(Hex2EncRule),
+		StandardIdentRule,
+	)
+	tests := []struct {
+		src  string
+		pos  int
+		span string
+	}{
+		{`x "abc`, 6, ""},
+		{"x \"ab\ncd", 5, ""},
+		{`x /* abc`, 8, ""},
+		{`x "a\x4g"`, 6, `4`},
+		{`x "a\q"`, 5, `q`},
+		{`x # y`, 2, `#`},
+	}
+	for _, test := range tests {
+		t.Run(test.src, func(t *testing.T) {
+			var errs Errors
+			for tok := range Tokens("", test.src, rules) {
+				errs = append(errs, tok.Errs...)
+			}
+			if len(errs) != 1 {
+				t.Fatalf("\n have: %v \n want: 1 error", errs)
+			}
+			if errs[0].Pos.Offset != test.pos {
+				t.Errorf("\n have: %v \n want: offset %v", errs[0].Pos, test.pos)
+			}
+			if errs[0].End.Line == 0 {
+				if test.span != "" {
+					t.Errorf("\n have: no end \n want: %q", test.span)
+				}
+				return
+			}
+			span := test.src[errs[0].Pos.Offset:errs[0].End.Offset]
+			if span != test.span {
+				t.Errorf("\n have: %q \n want: %q", span, test.span)
+			}
+		})
+	}
+}
@@ -15,12 +15,16 @@ type RuleSet struct {
 	preTokenFunc  func(*Scanner)
 	postTokenFunc func(*Scanner, Token) Token
 	noMatchFunc   func(*Scanner)
+	modes         map[string]RuleSet
+	longest       bool
+	dispatch      *dispatch
 }
 
 func NewRuleSet(rules ...Rule) RuleSet {
 	return RuleSet{
 		rules:       rules,
 		noMatchFunc: UnexpectedRune(),
+		dispatch:    newDispatch(rules),
 	}
 }
 
@@ -39,43 +43,131 @@ func (r RuleSet) WithNoMatchFunc(fn func(*Scanner)) RuleSet {
 	return r
 }
 
+// WithLongestMatch changes how a rule is selected for the next token. By
+// default, the first rule that matches is used. When b is true, every rule is
+// evaluated from the same starting point and the rule that consumes the most
+// input is used. If more than one rule has the longest match, the rule
+// declared first is used.
+func (r RuleSet) WithLongestMatch(b bool) RuleSet {
+	r.longest = b
+	return r
+}
+
+// WithMode adds a named rule set that is used in place of this one when
+// the mode is on top of the scanner's mode stack. Modes are pushed and popped
+// with Scanner.PushMode and Scanner.PopMode, or with a ModeRule.
+func (r RuleSet) WithMode(name string, rules RuleSet) RuleSet {
+	modes := make(map[string]RuleSet, len(r.modes)+1)
+	for k, v := range r.modes {
+		modes[k] = v
+	}
+	modes[name] = rules
+	r.modes = modes
+	return r
+}
+
+// Mode returns the rule set for the named mode. The empty string names the
+// rule set itself.
+func (r RuleSet) Mode(name string) (RuleSet, bool) {
+	if name == "" {
+		return r, true
+	}
+	rules, ok := r.modes[name]
+	return rules, ok
+}
+
 func (r RuleSet) Eval(s *Scanner) bool {
-	for _, r := range r.rules {
-		if r.Eval(s) {
+	if r.longest {
+		return r.evalLongest(s)
+	}
+	rules, index, starts := r.candidates(s.This)
+	for i, rule := range rules {
+		if starts != nil && starts[i] != nil && !starts[i](s.This) {
+			continue
+		}
+		if s.evalResumable(rule) {
+			s.suspended(i, index)
 			return true
 		}
 	}
 	return false
 }
 
+func (r RuleSet) evalLongest(s *Scanner) bool {
+	s.holdMarks()
+	defer s.releaseMarks()
+
+	start := s.Mark()
+	var best Mark
+	bestEnd := -1
+	bestIdx := 0
+	rules, index, starts := r.candidates(s.This)
+	for i, rule := range rules {
+		if starts != nil && starts[i] != nil && !starts[i](s.This) {
+			continue
+		}
+		if s.evalResumable(rule) && s.Pos.Offset > bestEnd {
+			best = s.Mark()
+			bestEnd = s.Pos.Offset
+			bestIdx = i
+		}
+		s.Reset(start)
+	}
+	if bestEnd < 0 {
+		return false
+	}
+	s.Reset(best)
+	s.suspended(bestIdx, index)
+	return true
+}
+
 func (r RuleSet) Next(s *Scanner) Token {
 	var tok Token
 	start := s.Pos
 
+	s.holdMarks()
+	defer s.releaseMarks()
+
 	for {
-		if r.preTokenFunc != nil {
-			r.preTokenFunc(s)
+		mode := s.Mode()
+		rules, ok := r.Mode(mode)
+		if !ok {
+			s.PopMode()
+			if !s.HasMore() {
+				continue
+			}
+			s.Type = ErrorType
+			s.Errs = append(s.Errs, Error{
+				Pos:     s.Pos,
+				Message: fmt.Sprintf("unknown mode: %v", Quote(mode)),
+				Code:    "mode.unknown",
+			})
+			return s.Emit()
+		}
+		if rules.preTokenFunc != nil {
+			rules.preTokenFunc(s)
 		}
 
-		match := false
-		for _, rule := range r.rules {
-			if match = rule.Eval(s); match {
-				tok = s.Emit()
-				break
+		if !rules.Eval(s) {
+			if s.HasMore() {
+				rules.noMatchFunc(s)
 			}
-		}
-		if !match {
-			r.noMatchFunc(s)
 			return s.Emit()
 		}
-		if r.postTokenFunc != nil {
-			tok = r.postTokenFunc(s, tok)
+		tok = s.Emit()
+		if rules.postTokenFunc != nil {
+			tok = rules.postTokenFunc(s, tok)
 		}
 		if tok.IsValid() {
 			break
 		}
+		s.addTrivia(tok)
 		if s.Pos == start {
-			panic("scanner did not advance")
+			s.halt(Error{
+				Pos:   s.Pos,
+				Cause: ErrNotAdvancing,
+			})
+			return s.Emit()
 		}
 		start = s.Pos
 	}
@@ -121,7 +213,6 @@ func (r CharEncRule) Eval(s *Scanner) bool {
 	if !ok {
 		return false
 	}
-	s.Lit.WriteRune(s.This)
 	s.Val.WriteRune(to)
 	s.Skip()
 	return true
@@ -153,81 +244,148 @@ func (r ClassRule) Eval(s *Scanner) bool {
 }
 
 type CommentRule struct {
-	begin LiteralRule
-	end   LiteralRule
-	keep  *bool
+	begin   LiteralRule
+	end     LiteralRule
+	keep    bool
+	keepRef *bool
 }
 
 func NewCommentRule(begin LiteralRule, end LiteralRule) CommentRule {
-	var keep bool
 	return CommentRule{
 		begin: begin.WithSkip(true),
 		end:   end.WithSkip(true),
-		keep:  &keep,
 	}
 }
 
-func (r CommentRule) WithKeep(keep *bool) CommentRule {
+// WithKeep emits comments as tokens when keep is true. Otherwise, comments
+// are discarded.
+func (r CommentRule) WithKeep(keep bool) CommentRule {
 	r.keep = keep
+	r.keepRef = nil
+	return r
+}
+
+// WithKeepRef emits comments as tokens when the value of keep is true at
+// the time the comment is scanned. This allows a shared rule set to be
+// configured after it is built. The value must not be changed while a scan
+// is in progress.
+func (r CommentRule) WithKeepRef(keep *bool) CommentRule {
+	r.keepRef = keep
 	return r
 }
 
+func (r CommentRule) keeps() bool {
+	if r.keepRef != nil {
+		return *r.keepRef
+	}
+	return r.keep
+}
+
 func (r CommentRule) Eval(s *Scanner) bool {
 	if !r.begin.Eval(s) {
 		return false
 	}
+	r.scan(s)
+	return true
+}
+
+// Resume continues a comment that was not terminated on the previous line.
+func (r CommentRule) Resume(s *Scanner, _ string) bool {
+	r.scan(s)
+	return true
+}
+
+func (r CommentRule) scan(s *Scanner) {
 	var action func()
-	if *r.keep {
+	if r.keeps() {
 		s.Type = CommentType
 		action = s.Keep
 	} else {
 		action = s.Skip
 	}
 
-	UntilRule(s, r.end, action)
-	return true
+	for s.HasMore() {
+		if r.end.Eval(s) {
+			return
+		}
+		action()
+	}
+	// Comments that end with a newline also end at the end of the input
+	if !r.end.Start()('\n') && !s.Suspend("") {
+		s.illegal(IncompleteInput, "comment.unterminated", "comment not terminated")
+	}
 }
 
 type HexEncRule struct {
 	flag   rune
 	digits int
 	asByte bool
+	err    error
 }
 
+// NewHexEncRule returns a rule that decodes a hexadecimal escape sequence
+// starting with flag. The number of digits must be 2, 4, or 8. The
+// configuration is checked once here and a rule that is not valid reports
+// the error when evaluated.
 func NewHexEncRule(flag rune, digits int) HexEncRule {
-	if digits != 2 && digits != 4 && digits != 8 {
-		panic(fmt.Sprintf("invalid digits '%v' for hex encoding map", digits))
-	}
-	return HexEncRule{flag: flag, digits: digits}
+	r := HexEncRule{flag: flag, digits: digits}
+	r.err = r.check()
+	return r
+}
+
+// NewValidHexEncRule is like NewHexEncRule but returns an error if the
+// number of digits is not supported.
+func NewValidHexEncRule(flag rune, digits int) (HexEncRule, error) {
+	r := NewHexEncRule(flag, digits)
+	return r, r.err
 }
 
+// AsByte decodes the sequence as a single byte instead of a rune. The number
+// of digits must be 2.
 func (r HexEncRule) AsByte(b bool) HexEncRule {
-	if b && r.digits != 2 {
-		panic("digits must be 2 for encoding bytes")
-	}
 	r.asByte = b
+	r.err = r.check()
 	return r
 }
 
+func (r HexEncRule) Validate() error {
+	return r.err
+}
+
+func (r HexEncRule) check() error {
+	if r.digits != 2 && r.digits != 4 && r.digits != 8 {
+		return fmt.Errorf("%w: invalid digits '%v' for hex encoding", ErrInvalidRule, r.digits)
+	}
+	if r.asByte && r.digits != 2 {
+		return fmt.Errorf("%w: digits must be 2 for encoding bytes", ErrInvalidRule)
+	}
+	return nil
+}
+
 func (r HexEncRule) Eval(s *Scanner) bool {
 	if s.This != r.flag {
 		return false
 	}
+	if r.err != nil {
+		s.InvalidRule(r.err)
+		return true
+	}
 	s.Skip()
+	start := s.Pos
 	digits := make([]rune, r.digits)
 	for i := 0; i < r.digits; i++ {
 		ch := s.Peek(i)
 		if !IsDigit0F(ch) {
-			s.Illegal("invalid encoding: %v", Quote(string(digits[:i])))
 			Repeat(s.Keep, i)
+			s.illegalAt(start, InvalidInput, "esc.invalid", "invalid encoding: %v", Quote(string(digits[:i])))
 			return true
 		}
 		digits[i] = s.Peek(i)
 	}
 	val, err := strconv.ParseUint(string(digits), 16, 32)
 	if err != nil {
-		s.Illegal("invalid encoding: %v", Quote(string(digits)))
 		Repeat(s.Keep, r.digits)
+		s.illegalAt(start, InvalidInput, "esc.invalid", "invalid encoding: %v", Quote(string(digits)))
 		return true
 	}
 	if r.asByte {
@@ -235,8 +393,8 @@ func (r HexEncRule) Eval(s *Scanner) bool {
 	} else {
 		ch := rune(val)
 		if !utf8.ValidRune(ch) {
-			s.Illegal("invalid encoding: %v", Quote(string(digits)))
 			Repeat(s.Keep, r.digits)
+			s.illegalAt(start, InvalidInput, "esc.invalid", "invalid encoding: %v", Quote(string(digits)))
 			return true
 		}
 		s.Val.WriteRune(ch)
@@ -431,7 +589,7 @@ func (r NumRule) WithLeadingDigitSepAllowed(b bool) NumRule {
 }
 
 func (r NumRule) WithLeadingZeroAllowed(b bool) NumRule {
-	r.leadingZeroAllowed = false
+	r.leadingZeroAllowed = b
 	return r
 }
 
@@ -550,20 +708,21 @@ func (r OctEncRule) Eval(s *Scanner) bool {
 	if !IsDigit07(s.This) {
 		return false
 	}
+	start := s.Pos
 	digits := make([]rune, 3)
 	for i := 0; i < 3; i++ {
 		ch := s.Peek(i)
 		if !IsDigit07(ch) {
-			s.Illegal("invalid encoding: %v", Quote(string(digits[:i])))
 			Repeat(s.Keep, i)
+			s.illegalAt(start, InvalidInput, "esc.invalid", "invalid encoding: %v", Quote(string(digits[:i])))
 			return true
 		}
 		digits[i] = ch
 	}
 	val, err := strconv.ParseUint(string(digits), 8, 8)
 	if err != nil {
-		s.Illegal("invalid encoding: %v", Quote(string(digits)))
 		Repeat(s.Keep, 3)
+		s.illegalAt(start, InvalidInput, "esc.invalid", "invalid encoding: %v", Quote(string(digits)))
 		return true
 	}
 	Repeat(s.Skip, 3)
@@ -583,6 +742,7 @@ type StrRule struct {
 	maxLen      uint
 	optTerm     bool
 	nesting     bool
+	err         error
 }
 
 func NewStrRule(begin rune, end rune) StrRule {
@@ -623,14 +783,24 @@ func (r StrRule) WithOptionalTerminator(t bool) StrRule {
 	return r
 }
 
+// WithNesting allows for strings to contain nested begin and end runes. This
+// is only possible when the begin and end runes are different.
 func (r StrRule) WithNesting(t bool) StrRule {
-	if r.begin == r.end {
-		panic("nesting not possible")
+	r.nesting = t
+	r.err = nil
+	if t && r.begin == r.end {
+		r.err = fmt.Errorf("%w: nesting not possible when begin and end are the same", ErrInvalidRule)
 	}
-	r.nesting = true
 	return r
 }
 
+func (r StrRule) Validate() error {
+	if r.err != nil {
+		return r.err
+	}
+	return r.escapeRules.Validate()
+}
+
 func (r StrRule) recover(s *Scanner) {
 	Until(s, Rune(r.end), s.Skip)
 	s.Skip()
@@ -640,15 +810,33 @@ func (r StrRule) Eval(s *Scanner) bool {
 	if s.This != r.begin {
 		return false
 	}
+	if r.err != nil {
+		s.InvalidRule(r.err)
+		return true
+	}
 	s.Skip()
+	return r.scan(s, 1)
+}
 
+// Resume continues a multiline string that was not terminated on the
+// previous line. The data is the nesting level.
+func (r StrRule) Resume(s *Scanner, data string) bool {
+	level, err := strconv.Atoi(data)
+	if err != nil || level < 1 {
+		level = 1
+	}
+	return r.scan(s, level)
+}
+
+// scan reads the string after the beginning rune. The level is the number
+// of strings that are open when nesting.
+func (r StrRule) scan(s *Scanner, level int) bool {
 	s.Type = StrType
 	if r.type_ != "" {
 		s.Type = r.type_
 	}
 
 	length := uint(0)
-	level := 1
 	for s.HasMore() {
 		if r.nesting && s.This == r.begin {
 			level++
@@ -663,7 +851,7 @@ func (r StrRule) Eval(s *Scanner) bool {
 
 		length++
 		if r.maxLen > 0 && length > r.maxLen {
-			s.Illegal("too many characters (%v)", length)
+			s.illegal(InvalidInput, "str.too-long", "too many characters (%v)", length)
 			r.recover(s)
 			return true
 		}
@@ -672,7 +860,7 @@ func (r StrRule) Eval(s *Scanner) bool {
 		case s.This == '\n':
 			if !r.multiline {
 				if !r.optTerm {
-					s.Illegal("not terminated")
+					s.illegal(InvalidInput, "str.unterminated", "not terminated")
 				}
 				return true
 			}
@@ -681,9 +869,9 @@ func (r StrRule) Eval(s *Scanner) bool {
 			s.Skip()
 			if s.This == r.end || s.This == r.escape {
 				s.Keep()
-			} else if !r.escapeRules.Eval(s) {
-				s.Illegal("invalid escape sequence: '%c%c'", r.escape, s.This)
+			} else if pos, ch := s.Pos, s.This; !r.escapeRules.Eval(s) {
 				s.Keep()
+				s.illegalAt(pos, InvalidInput, "str.invalid-escape", "invalid escape sequence: '%c%c'", r.escape, ch)
 				r.recover(s)
 				return true
 			} else if len(s.Errs) > 0 {
@@ -694,8 +882,11 @@ func (r StrRule) Eval(s *Scanner) bool {
 			s.Keep()
 		}
 	}
+	if r.multiline && s.Suspend(strconv.Itoa(level)) {
+		return true
+	}
 	if !r.optTerm {
-		s.Illegal("not terminated")
+		s.illegal(IncompleteInput, "str.unterminated", "not terminated")
 	}
 	return true
 }
@@ -1,6 +1,10 @@
 package scan
 
-import "testing"
+import (
+	"errors"
+	"strings"
+	"testing"
+)
 
 func TestBin(t *testing.T) {
 	rules := NewRuleSet(BinRule)
@@ -255,3 +259,111 @@ func TestStrNesting(t *testing.T) {
 	}
 	RunTests(t, rules, tests)
 }
+
+func TestLongestMatch(t *testing.T) {
+	rules := NewRuleSet(
+		SkipSpaceRule,
+		Literal("for", "if", "."),
+		Literal("..."),
+		StandardIdentRule,
+		RealRule,
+	).WithLongestMatch(true)
+	tests := []Test{
+		NewTest("for format", "for", 1, 1, "for").
+			And("format", 1, 5, IdentType),
+		NewTest("iffy", "iffy", 1, 1, IdentType),
+		NewTest("... .5 .", "...", 1, 1, "...").
+			And(".5", 1, 5, RealType).
+			And(".", 1, 8, "."),
+	}
+	RunTests(t, rules, tests)
+
+	// Same rules without longest match splits the identifier
+	tests = []Test{
+		NewTest("format", "for", 1, 1, "for").
+			And("mat", 1, 4, IdentType),
+	}
+	RunTests(t, rules.WithLongestMatch(false), tests)
+}
+
+func TestLongestMatchReader(t *testing.T) {
+	rules := NewRuleSet(
+		SkipSpaceRule,
+		Literal("for"),
+		StandardIdentRule,
+	).WithLongestMatch(true)
+	s := NewScanner("", strings.NewReader("for  format forma"))
+	toks := NewRunner(s, rules).All()
+	want := []string{"for", "format", "forma"}
+	if len(toks) != len(want) {
+		t.Fatalf("\n have: %v \n want: %v", toks, want)
+	}
+	for i, tok := range toks {
+		if tok.Val != want[i] {
+			t.Errorf("\n have: %v \n want: %v", tok.Val, want[i])
+		}
+	}
+}
+
+type stallRule struct{}
+
+func (r stallRule) Eval(s *Scanner) bool {
+	return true
+}
+
+func TestRuleSetNotAdvancing(t *testing.T) {
+	rules := NewRuleSet(stallRule{})
+	tests := []Test{
+		NewTest("abc", "", 1, 1, ErrorType).
+			WithError("1:1: error: scanner is not advancing"),
+	}
+	RunTests(t, rules, tests)
+}
+
+func TestInvalidRules(t *testing.T) {
+	tests := []struct {
+		name string
+		rule Rule
+	}{
+		{"hex digits", NewHexEncRule('x', 3)},
+		{"hex byte", Hex4EncRule.AsByte(true)},
+		{"nesting", NewStrRule('"', '"').WithNesting(true)},
+		{"escape", StrDoubleQuoteRule.WithEscapeRules(NewHexEncRule('x', 3))},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			_, err := NewValidRuleSet(test.rule)
+			if !errors.Is(err, ErrInvalidRule) {
+				t.Errorf("\n have: %v \n want: %v", err, ErrInvalidRule)
+			}
+		})
+	}
+
+	if _, err := NewValidRuleSet(Hex2EncRule.AsByte(true), StrDoubleQuoteRule); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+	if _, err := NewValidHexEncRule('x', 3); !errors.Is(err, ErrInvalidRule) {
+		t.Errorf("\n have: %v \n want: %v", err, ErrInvalidRule)
+	}
+	if _, err := NewValidHexEncRule('u', 4); err != nil {
+		t.Errorf("unexpected error: %v", err)
+	}
+}
+
+func TestInvalidRuleEval(t *testing.T) {
+	rules := NewRuleSet(
+		StrDoubleQuoteRule.WithEscapeRules(NewHexEncRule('x', 3)),
+		NewStrRule('|', '|').WithNesting(true),
+	)
+	tests := []Test{
+		NewTest(`"\x123"`, "x", 1, 1, ErrorType).
+			WithError("1:3: error: invalid rule: invalid digits '3' for hex encoding"),
+		NewTest("|a|", "|", 1, 1, ErrorType).
+			WithError("1:1: error: invalid rule: nesting not possible when begin and end are the same").
+			And("a", 1, 2, IllegalType).
+			WithError(`1:2: error: unexpected "a"`).
+			And("|", 1, 3, ErrorType).
+			WithError("1:3: error: invalid rule: nesting not possible when begin and end are the same"),
+	}
+	RunTests(t, rules, tests)
+}
@@ -1,19 +1,93 @@
 package scan
 
+import (
+	"context"
+	"errors"
+	"iter"
+)
+
+var (
+	// ErrMaxTokenLen is the cause of the error reported when a token is
+	// longer than the limit set with WithMaxTokenLen.
+	ErrMaxTokenLen = errors.New("maximum token length exceeded")
+
+	// ErrMaxTokens is the cause of the error reported when more tokens are
+	// found than the limit set with WithMaxTokens.
+	ErrMaxTokens = errors.New("maximum number of tokens exceeded")
+
+	// ErrMaxErrors is the cause of the error reported when the number of
+	// errors reaches the limit set with WithMaxErrors.
+	ErrMaxErrors = errors.New("maximum number of errors exceeded")
+)
+
+// RunnerOption configures optional behavior of a Runner.
+type RunnerOption func(*Runner)
+
+// WithContext stops the runner when ctx is done. The context is checked
+// before each token is scanned and periodically while a token is being
+// scanned. The cause of the error reported is the error from the context.
+func WithContext(ctx context.Context) RunnerOption {
+	return func(r *Runner) {
+		r.ctx = ctx
+	}
+}
+
+// WithMaxTokenLen stops the runner when a token is longer than n bytes.
+func WithMaxTokenLen(n int) RunnerOption {
+	return func(r *Runner) {
+		r.maxTokenLen = n
+	}
+}
+
+// WithMaxTokens stops the runner when there are more than n tokens.
+func WithMaxTokens(n int) RunnerOption {
+	return func(r *Runner) {
+		r.maxTokens = n
+	}
+}
+
+// WithMaxErrors stops the runner once the tokens scanned have a total of n
+// errors.
+func WithMaxErrors(n int) RunnerOption {
+	return func(r *Runner) {
+		r.maxErrors = n
+	}
+}
+
+// Runner uses a rule set to scan tokens and holds the current and next
+// token. When a limit set by an option is hit, the runner emits a token with
+// a type of ErrorType that has an error with the limit as its cause. The
+// end of text follows.
 type Runner struct {
-	scan  *Scanner
-	Rules RuleSet
-	This  Token
-	Next  Token
+	scan        *Scanner
+	Rules       RuleSet
+	This        Token
+	Next        Token
+	ctx         context.Context
+	maxTokenLen int
+	maxTokens   int
+	maxErrors   int
+	trivia      bool
+	brackets    map[string]string
+	ntoks       int
+	nerrs       int
+	open        []string
+	incomplete  bool
 }
 
-func NewRunner(scan *Scanner, rules RuleSet) *Runner {
+func NewRunner(scan *Scanner, rules RuleSet, opts ...RunnerOption) *Runner {
 	r := &Runner{
 		scan:  scan,
 		Rules: rules,
 	}
-	r.This = rules.Next(scan)
-	r.Next = rules.Next(scan)
+	for _, opt := range opts {
+		opt(r)
+	}
+	scan.ctx = r.ctx
+	scan.maxTokenLen = r.maxTokenLen
+	scan.keepTrivia = r.trivia
+	r.This = r.next(nil)
+	r.Next = r.next(&r.This)
 	return r
 }
 
@@ -25,8 +99,9 @@ func (r *Runner) Scan() Token {
 	if r.This.IsEndOfText() {
 		return r.This
 	}
+	next := r.next(&r.Next)
 	r.This = r.Next
-	r.Next = r.Rules.Next(r.scan)
+	r.Next = next
 	return r.This
 }
 
@@ -38,3 +113,76 @@ func (r *Runner) All() []Token {
 	}
 	return toks
 }
+
+// Tokens returns an iterator over the tokens starting with the current
+// token and ending before the end of text. If the loop is stopped early,
+// the token at which it stopped remains the current token.
+func (r *Runner) Tokens() iter.Seq[Token] {
+	return func(yield func(Token) bool) {
+		for r.HasMore() {
+			if !yield(r.This) {
+				return
+			}
+			r.Scan()
+		}
+	}
+}
+
+// TokensWithErrs is the same as Tokens but also yields the errors found in
+// each token. The error is nil when the token has no errors.
+func (r *Runner) TokensWithErrs() iter.Seq2[Token, error] {
+	return func(yield func(Token, error) bool) {
+		for tok := range r.Tokens() {
+			var err error
+			if len(tok.Errs) > 0 {
+				err = tok.Errs
+			}
+			if !yield(tok, err) {
+				return
+			}
+		}
+	}
+}
+
+// Tokens returns an iterator over the tokens found in src using rules. The
+// source is scanned again each time the iterator is used.
+func Tokens(name string, src string, rules RuleSet, opts ...RunnerOption) iter.Seq[Token] {
+	return func(yield func(Token) bool) {
+		s := NewScannerFromString(name, src)
+		NewRunner(s, rules, opts...).Tokens()(yield)
+	}
+}
+
+// next scans the next token. The token previously scanned, if any, is prev
+// and receives its trailing trivia.
+func (r *Runner) next(prev *Token) Token {
+	if r.ctx != nil {
+		if err := r.ctx.Err(); err != nil {
+			r.stop(err)
+		}
+	}
+	if r.maxTokens > 0 && r.ntoks >= r.maxTokens {
+		r.stop(ErrMaxTokens)
+	}
+	tok := r.Rules.Next(r.scan)
+	r.attachTrivia(prev, &tok)
+	r.track(tok)
+	r.ntoks++
+	r.nerrs += len(tok.Errs)
+	if r.maxErrors > 0 && r.nerrs >= r.maxErrors {
+		r.stop(ErrMaxErrors)
+	}
+	return tok
+}
+
+// stop halts the scanner with err as the cause unless the end of the stream
+// has already been reached.
+func (r *Runner) stop(err error) {
+	if !r.scan.HasMore() {
+		return
+	}
+	r.scan.halt(Error{
+		Pos:   r.scan.Pos,
+		Cause: err,
+	})
+}
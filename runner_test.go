@@ -0,0 +1,164 @@
+package scan
+
+import (
+	"context"
+	"errors"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestRunnerLimits(t *testing.T) {
+	rules := NewRuleSet(
+		SkipSpaceRule,
+		Literal("!="),
+		NewWhileRule(IsLetter, WordType),
+		StrDoubleQuoteRule,
+	)
+
+	tests := []struct {
+		name  string
+		src   string
+		opt   RunnerOption
+		types []string
+		err   error
+	}{
+		{"token len", "ab abcdefgh ab", WithMaxTokenLen(4), []string{WordType, WordType, ErrorType}, ErrMaxTokenLen},
+		{"token len ok", "abcd", WithMaxTokenLen(4), []string{WordType}, nil},
+		{"token len str", `"abcdefgh" ab`, WithMaxTokenLen(4), []string{StrType, ErrorType}, ErrMaxTokenLen},
+		{"tokens", "a b c d", WithMaxTokens(2), []string{WordType, WordType, ErrorType}, ErrMaxTokens},
+		{"tokens many", "a b c d e f g h", WithMaxTokens(3), []string{WordType, WordType, WordType, ErrorType}, ErrMaxTokens},
+		{"tokens ok", "a b", WithMaxTokens(2), []string{WordType, WordType}, nil},
+		{"errors", "a ! ! !", WithMaxErrors(2), []string{WordType, IllegalType, IllegalType, ErrorType}, ErrMaxErrors},
+		{"errors ok", "a ! !", WithMaxErrors(2), []string{WordType, IllegalType, IllegalType}, nil},
+	}
+
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			for _, s := range newTestScanners(test.src) {
+				r := NewRunner(s, rules, test.opt)
+				toks := r.All()
+				if len(toks) != len(test.types) {
+					t.Fatalf("\n have: %v \n want: %v", toks, test.types)
+				}
+				for i, tok := range toks {
+					if tok.Type != test.types[i] {
+						t.Errorf("\n have: %v \n want: %v", tok.Type, test.types[i])
+					}
+					if tok.IsIncomplete() {
+						t.Errorf("unexpected incomplete token: %v", tok)
+					}
+				}
+				if tok := r.Scan(); !tok.IsEndOfText() {
+					t.Errorf("\n have: %v \n want: end of text", tok)
+				}
+				if test.err == nil {
+					continue
+				}
+				last := toks[len(toks)-1]
+				if !errors.Is(last.Errs[0], test.err) {
+					t.Errorf("\n have: %v \n want: %v", last.Errs, test.err)
+				}
+			}
+		})
+	}
+}
+
+func TestRunnerContext(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rules := NewRuleSet(SkipSpaceRule, Literal("!="), WordRule)
+	for name, s := range newTestScanners("abc def ghi") {
+		t.Run(name, func(t *testing.T) {
+			toks := NewRunner(s, rules, WithContext(ctx)).All()
+			if len(toks) != 1 || !errors.Is(toks[0].Errs[0], context.Canceled) {
+				t.Errorf("\n have: %v \n want: %v", toks, context.Canceled)
+			}
+		})
+	}
+}
+
+// cancelReader cancels a context once a number of bytes have been read.
+type cancelReader struct {
+	src    *strings.Reader
+	after  int
+	cancel context.CancelFunc
+}
+
+func (r *cancelReader) Read(p []byte) (int, error) {
+	n, err := r.src.Read(p[:min(len(p), 512)])
+	r.after -= n
+	if r.after <= 0 {
+		r.cancel()
+	}
+	return n, err
+}
+
+func TestRunnerContextInToken(t *testing.T) {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+
+	src := "\"" + strings.Repeat("a", 100_000)
+	r := &cancelReader{src: strings.NewReader(src), after: 4096, cancel: cancel}
+	s := NewScanner("", r)
+	toks := NewRunner(s, NewRuleSet(StrDoubleQuoteRule), WithContext(ctx)).All()
+	last := toks[len(toks)-1]
+	if last.Type != ErrorType || !errors.Is(last.Errs[0], context.Canceled) {
+		t.Fatalf("\n have: %v \n want: %v", last, context.Canceled)
+	}
+	if toks[0].End.Offset >= len(src) {
+		t.Errorf("scanned to end of input")
+	}
+}
+
+func TestTokens(t *testing.T) {
+	rules := NewRuleSet(SkipSpaceRule, NewWhileRule(IsLetter, WordType))
+
+	var have []string
+	for tok := range Tokens("", "a b c d", rules) {
+		if tok.Val == "c" {
+			break
+		}
+		have = append(have, tok.Val)
+	}
+	want := []string{"a", "b"}
+	if !slices.Equal(have, want) {
+		t.Errorf("\n have: %v \n want: %v", have, want)
+	}
+}
+
+func TestTokensBreak(t *testing.T) {
+	rules := NewRuleSet(SkipSpaceRule, NewWhileRule(IsLetter, WordType))
+	r := NewRunner(NewScannerFromString("", "a b c d"), rules)
+
+	for tok := range r.Tokens() {
+		if tok.Val == "b" {
+			break
+		}
+	}
+	if r.This.Val != "b" {
+		t.Fatalf("\n have: %v \n want: b", r.This.Val)
+	}
+	var have []string
+	for tok := range r.Tokens() {
+		have = append(have, tok.Val)
+	}
+	want := []string{"b", "c", "d"}
+	if !slices.Equal(have, want) {
+		t.Errorf("\n have: %v \n want: %v", have, want)
+	}
+}
+
+func TestTokensWithErrs(t *testing.T) {
+	rules := NewRuleSet(SkipSpaceRule, NewWhileRule(IsLetter, WordType))
+	r := NewRunner(NewScannerFromString("", "a ! b"), rules)
+
+	var errs []error
+	for _, err := range r.TokensWithErrs() {
+		errs = append(errs, err)
+	}
+	if len(errs) != 3 || errs[0] != nil || errs[1] == nil || errs[2] != nil {
+		t.Errorf("unexpected errors: %v", errs)
+	}
+}
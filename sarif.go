@@ -0,0 +1,131 @@
+package scan
+
+import (
+	"encoding/json"
+	"io"
+	"net/url"
+	"path/filepath"
+	"strings"
+)
+
+// WriteSARIF writes errs to w as a SARIF 2.1.0 log so that they can be
+// read by code scanning tools. The tool is the name of the program that
+// found the errors. The name of each error position is the path of the
+// file and the error code is used as the rule identifier. Relative paths
+// are written as URI references relative to %SRCROOT% and absolute paths
+// as file URLs.
+func WriteSARIF(w io.Writer, tool string, errs Errors) error {
+	type region struct {
+		StartLine   int `json:"startLine"`
+		StartColumn int `json:"startColumn"`
+		EndLine     int `json:"endLine,omitempty"`
+		EndColumn   int `json:"endColumn,omitempty"`
+	}
+	type artifactLocation struct {
+		URI       string `json:"uri"`
+		URIBaseID string `json:"uriBaseId,omitempty"`
+	}
+	type physicalLocation struct {
+		ArtifactLocation artifactLocation `json:"artifactLocation"`
+		Region           region           `json:"region"`
+	}
+	type location struct {
+		PhysicalLocation physicalLocation `json:"physicalLocation"`
+	}
+	type message struct {
+		Text string `json:"text"`
+	}
+	type result struct {
+		RuleID    string     `json:"ruleId,omitempty"`
+		Level     string     `json:"level"`
+		Message   message    `json:"message"`
+		Locations []location `json:"locations"`
+	}
+	type rule struct {
+		ID string `json:"id"`
+	}
+	type driver struct {
+		Name  string `json:"name"`
+		Rules []rule `json:"rules,omitempty"`
+	}
+	type toolInfo struct {
+		Driver driver `json:"driver"`
+	}
+	type run struct {
+		Tool       toolInfo `json:"tool"`
+		ColumnKind string   `json:"columnKind"`
+		Results    []result `json:"results"`
+	}
+	type sarifLog struct {
+		Schema  string `json:"$schema"`
+		Version string `json:"version"`
+		Runs    []run  `json:"runs"`
+	}
+
+	r := run{
+		Tool: toolInfo{Driver: driver{Name: tool}},
+		// Columns are counted in runes
+		ColumnKind: "unicodeCodePoints",
+		Results:    []result{},
+	}
+	seen := make(map[string]bool)
+	for _, e := range errs {
+		if e.Code != "" && !seen[e.Code] {
+			seen[e.Code] = true
+			r.Tool.Driver.Rules = append(r.Tool.Driver.Rules, rule{ID: e.Code})
+		}
+		art := artifactLocation{}
+		art.URI, art.URIBaseID = sarifURI(e.Pos.Name)
+		reg := region{StartLine: e.Pos.Line, StartColumn: e.Pos.Col}
+		if e.End != (Pos{}) {
+			reg.EndLine, reg.EndColumn = e.End.Line, e.End.Col
+		}
+		r.Results = append(r.Results, result{
+			RuleID:  e.Code,
+			Level:   sarifLevel(e.Severity),
+			Message: message{Text: e.Text()},
+			Locations: []location{{
+				PhysicalLocation: physicalLocation{
+					ArtifactLocation: art,
+					Region:           reg,
+				},
+			}},
+		})
+	}
+
+	enc := json.NewEncoder(w)
+	enc.SetIndent("", "  ")
+	return enc.Encode(sarifLog{
+		Schema:  "https://json.schemastore.org/sarif-2.1.0.json",
+		Version: "2.1.0",
+		Runs:    []run{r},
+	})
+}
+
+// sarifURI returns the URI for the file found at path and the identifier
+// of the base URI that it is relative to, if any.
+func sarifURI(path string) (string, string) {
+	if path == "" {
+		return "", ""
+	}
+	u := url.URL{Path: filepath.ToSlash(path)}
+	if !filepath.IsAbs(path) {
+		return u.String(), "%SRCROOT%"
+	}
+	// Windows paths start with a volume name instead of a slash
+	if !strings.HasPrefix(u.Path, "/") {
+		u.Path = "/" + u.Path
+	}
+	u.Scheme = "file"
+	return u.String(), ""
+}
+
+func sarifLevel(v Severity) string {
+	switch v {
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "note"
+	}
+	return "error"
+}
@@ -0,0 +1,92 @@
+package scan
+
+import (
+	"encoding/json"
+	"net/url"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestWriteSARIF(t *testing.T) {
+	errs := Errors{
+		{Pos: Pos{Name: "a.txt", Line: 1, Col: 5}, Message: "not terminated", Code: "str.unterminated"},
+		{Pos: Pos{Name: "a.txt", Line: 2, Col: 1}, End: Pos{Name: "a.txt", Line: 2, Col: 3}, Message: "odd", Severity: SeverityInfo},
+	}
+	var b strings.Builder
+	if err := WriteSARIF(&b, "test", errs); err != nil {
+		t.Fatal(err)
+	}
+	var log struct {
+		Version string
+		Runs    []struct {
+			Results []struct {
+				RuleID    string
+				Level     string
+				Locations []struct {
+					PhysicalLocation struct {
+						ArtifactLocation struct{ URI, URIBaseID string }
+						Region           struct{ StartLine, StartColumn, EndLine, EndColumn int }
+					}
+				}
+			}
+		}
+	}
+	if err := json.Unmarshal([]byte(b.String()), &log); err != nil {
+		t.Fatal(err)
+	}
+	if log.Version != "2.1.0" || len(log.Runs) != 1 || len(log.Runs[0].Results) != 2 {
+		t.Fatalf("unexpected log: %v", b.String())
+	}
+	r0, r1 := log.Runs[0].Results[0], log.Runs[0].Results[1]
+	loc := r0.Locations[0].PhysicalLocation
+	if r0.RuleID != "str.unterminated" || r0.Level != "error" || loc.ArtifactLocation.URI != "a.txt" || loc.ArtifactLocation.URIBaseID != "%SRCROOT%" ||
+		loc.Region.StartLine != 1 || loc.Region.StartColumn != 5 || loc.Region.EndLine != 0 {
+		t.Errorf("unexpected result: %+v", r0)
+	}
+	if r1.RuleID != "" || r1.Level != "note" || r1.Locations[0].PhysicalLocation.Region.EndColumn != 3 {
+		t.Errorf("unexpected result: %+v", r1)
+	}
+}
+
+func TestSARIFURI(t *testing.T) {
+	abs, err := filepath.Abs(filepath.Join("dir", "my file.go"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []struct {
+		path string
+		uri  string
+		base string
+	}{
+		{"", "", ""},
+		{"a.txt", "a.txt", "%SRCROOT%"},
+		{filepath.Join("dir", "my file#1.go"), "dir/my%20file%231.go", "%SRCROOT%"},
+		{"a:b.go", "./a:b.go", "%SRCROOT%"},
+	}
+	for _, test := range tests {
+		uri, base := sarifURI(test.path)
+		if uri != test.uri || base != test.base {
+			t.Errorf("%v:\n have: %v %v \n want: %v %v", test.path, uri, base, test.uri, test.base)
+		}
+		if _, err := url.Parse(uri); err != nil {
+			t.Errorf("%v: %v", test.path, err)
+		}
+	}
+	uri, base := sarifURI(abs)
+	if base != "" || !strings.HasPrefix(uri, "file:///") || !strings.HasSuffix(uri, "/dir/my%20file.go") {
+		t.Errorf("%v:\n have: %v %v", abs, uri, base)
+	}
+}
+
+func TestErrorJSON(t *testing.T) {
+	e := Error{Pos: Pos{Line: 1, Col: 2, Offset: 1}, Message: "bad", Cause: ErrNotAdvancing, Kind: IncompleteInput, Code: "x.y"}
+	data, err := json.Marshal(e)
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := `{"severity":"error","code":"x.y","kind":"incomplete","message":"bad: scanner is not advancing","pos":{"line":1,"col":2,"offset":1}}`
+	if string(data) != want {
+		t.Errorf("\n have: %v \n want: %v", string(data), want)
+	}
+}
@@ -2,6 +2,8 @@ package scango
 
 import (
 	"fmt"
+	"strconv"
+	"unicode/utf8"
 
 	"github.com/blackchip-org/scan"
 )
@@ -102,38 +104,31 @@ func EscapeRules(type_ string) []scan.Rule {
 	}
 }
 
-var semiColonRequiredAfter = map[string]struct{}{
-	IdentType:     {},
-	IntType:       {},
-	FloatType:     {},
-	ImagType:      {},
-	RuneType:      {},
-	StringType:    {},
-	"break":       {},
-	"continue":    {},
-	"fallthrough": {},
-	"return":      {},
-	"++":          {},
-	"--":          {},
-	")":           {},
-	"]":           {},
-	"}":           {},
+// SemicolonRequiredAfter are the types of tokens that are followed by a
+// semicolon when a newline is found after them.
+var SemicolonRequiredAfter = []string{
+	IdentType, IntType, FloatType, ImagType, RuneType, StringType,
+	"break", "continue", "fallthrough", "return",
+	"++", "--", ")", "]", "}",
 }
 
+// AutoSemiInsertion returns a post token function that converts newlines
+// to semicolons when required.
 func AutoSemiInsertion() func(*scan.Scanner, scan.Token) scan.Token {
-	var last scan.Token
-	return func(_ *scan.Scanner, t scan.Token) scan.Token {
-		if t.Type == "\n" {
-			if _, yes := semiColonRequiredAfter[last.Type]; yes {
-				t.Type = ";"
-				t.Val = ";"
-			} else {
-				t = scan.Token{}
-			}
-		}
-		last = t
-		return t
+	return scan.InsertSemicolons("\n", SemicolonRequiredAfter...)
+}
+
+// Encode returns the Go source text for a token that does not have a
+// literal. It can be used with a scan.Printer.
+func Encode(tok scan.Token) string {
+	switch tok.Type {
+	case StringType:
+		return strconv.Quote(tok.Val)
+	case RuneType:
+		ch, _ := utf8.DecodeRuneInString(tok.Val)
+		return strconv.QuoteRune(ch)
 	}
+	return tok.Val
 }
 
 var isImag = scan.Rune('i')
@@ -149,28 +144,49 @@ func (r ImagSuffixRule) Eval(s *scan.Scanner) bool {
 	return false
 }
 
+func (r ImagSuffixRule) Describe() scan.Desc {
+	return scan.Desc{Kind: "class", Type: ImagType, Syntax: scan.DescribeClass(isImag)}
+}
+
 var ImagSuffix = ImagSuffixRule{}
 
+// Context holds the rule set for scanning Go source. The rule set can be
+// used for many scans at once. Comments are emitted as tokens when
+// KeepComments is true. It can be changed at any time except while a scan
+// is in progress.
 type Context struct {
 	KeepComments bool
 	RuleSet      scan.RuleSet
 }
 
+// NewContext returns a context that discards comments.
 func NewContext() *Context {
 	c := &Context{}
+	c.build()
+	return c
+}
+
+// WithKeepComments sets KeepComments.
+func (c *Context) WithKeepComments(keep bool) *Context {
+	c.KeepComments = keep
+	return c
+}
+
+// build creates the rule set. Rules that share a token type are labeled so
+// that each has its own name when the rule set is described.
+func (c *Context) build() {
 	c.RuleSet = scan.NewRuleSet(
-		Whitespace,
-		GenComment.WithKeep(&c.KeepComments),
-		LineComment.WithKeep(&c.KeepComments),
+		scan.Label(Whitespace, "whitespace"),
+		scan.Label(GenComment.WithKeepRef(&c.KeepComments), "genComment"),
+		scan.Label(LineComment.WithKeepRef(&c.KeepComments), "lineComment"),
 		Rune,
-		HexFloat,
-		Oct,
-		Bin,
-		IntFloat,
+		scan.Label(HexFloat, "hexFloat"),
+		scan.Label(Oct, "oct"),
+		scan.Label(Bin, "bin"),
+		scan.Label(IntFloat, "intFloat"),
 		String,
-		RawString,
+		scan.Label(RawString, "rawString"),
 		Ident,
-		Symbols,
+		scan.Label(Symbols, "symbols"),
 	).WithPostTokenFunc(AutoSemiInsertion())
-	return c
 }
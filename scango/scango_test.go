@@ -1,6 +1,12 @@
 package scango
 
 import (
+	_ "embed"
+	"flag"
+	"math/rand/v2"
+	"slices"
+	"strings"
+	"sync"
 	"testing"
 
 	"github.com/blackchip-org/scan"
@@ -215,10 +221,45 @@ func TestSemiColons(t *testing.T) {
 			And("42", 2, 1, IntType),
 		scan.NewTest("case\n42", "case", 1, 1, "case").
 			And("42", 2, 1, IntType),
+		scan.NewTest("\n42", "42", 2, 1, IntType),
 	}
 	scan.RunTests(t, ctx.RuleSet, tests)
 }
 
+func TestConcurrent(t *testing.T) {
+	ctx := NewContext()
+	srcs := []string{
+		source,
+		"a++\nb--\n",
+		"\nx := 1 /* c */\nreturn\n",
+		"func f() {\n\treturn 'x'\n}\n",
+	}
+
+	scanAll := func(src string) []scan.Token {
+		s := scan.NewScannerFromString("", src)
+		return scan.NewRunner(s, ctx.RuleSet).All()
+	}
+	var want [][]scan.Token
+	for _, src := range srcs {
+		want = append(want, scanAll(src))
+	}
+
+	var wg sync.WaitGroup
+	for n := 0; n < 8; n++ {
+		for i, src := range srcs {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				have := scanAll(src)
+				if !slices.EqualFunc(have, want[i], scan.Token.Equal) {
+					t.Errorf("tokens differ for source %v", i)
+				}
+			}()
+		}
+	}
+	wg.Wait()
+}
+
 func TestString(t *testing.T) {
 	ctx := NewContext()
 	tests := []scan.Test{
@@ -235,3 +276,99 @@ func TestString(t *testing.T) {
 	}
 	scan.RunTests(t, ctx.RuleSet, tests)
 }
+
+//go:embed scango.go
+var source string
+
+func TestRoundTrip(t *testing.T) {
+	for _, keep := range []bool{false, true} {
+		ctx := NewContext().WithKeepComments(keep)
+		s := scan.NewScannerFromString("scango.go", source)
+		r := scan.NewRunner(s, ctx.RuleSet, scan.WithTrivia(true))
+		toks := r.All()
+		for _, tok := range toks {
+			if tok.Type == IllegalType {
+				t.Fatalf("unexpected token: %v", tok)
+			}
+		}
+
+		var b strings.Builder
+		if err := scan.Print(&b, append(toks, r.This)); err != nil {
+			t.Fatal(err)
+		}
+		if have := b.String(); have != source {
+			t.Errorf("have:\n%v\nwant:\n%v", have, source)
+		}
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	src := "x := \"a\\tb\" // x\ny := '\\n'\n"
+	ctx := NewContext()
+	toks := scan.NewRunner(scan.NewScannerFromString("", src), ctx.RuleSet, scan.WithTrivia(true)).All()
+	for i, tok := range toks {
+		switch tok.Type {
+		case IdentType:
+			toks[i].Val = strings.ToUpper(tok.Val)
+		case StringType:
+			toks[i].Val = tok.Val + "\"c"
+		case RuneType:
+			toks[i].Val = "\t"
+		default:
+			continue
+		}
+		toks[i].Lit = ""
+	}
+
+	var b strings.Builder
+	if err := (scan.Printer{Encode: Encode}).Print(&b, toks); err != nil {
+		t.Fatal(err)
+	}
+	want := "X := \"a\\tb\\\"c\" // x\nY := '\\t'\n"
+	if have := b.String(); have != want {
+		t.Errorf("\n have: %q \n want: %q", have, want)
+	}
+}
+
+func TestRelex(t *testing.T) {
+	ctx := NewContext()
+	src := source[:2000]
+	inserts := []string{"", "x", "\n", " ", "}", "\"", "//", "1.5"}
+	old := scan.NewRunner(scan.NewScannerFromString("", src), ctx.RuleSet).All()
+
+	rnd := rand.New(rand.NewPCG(1, 2))
+	for i := 0; i < 200; i++ {
+		e := scan.Edit{Offset: rnd.IntN(len(src) + 1)}
+		e.Deleted = rnd.IntN(min(3, len(src)-e.Offset) + 1)
+		e.Inserted = inserts[rnd.IntN(len(inserts))]
+		edited := src[:e.Offset] + e.Inserted + src[e.Offset+e.Deleted:]
+
+		want := scan.NewRunner(scan.NewScannerFromString("", edited), ctx.RuleSet).All()
+		have := scan.Relex(edited, old, e, ctx.RuleSet)
+		if !slices.EqualFunc(have, want, scan.Token.Equal) {
+			t.Fatalf("edit %+v:\n have: %v \n want: %v", e, have, want)
+		}
+	}
+}
+
+func TestFeed(t *testing.T) {
+	ctx := NewContext()
+	src := source[:3000]
+	want := scan.NewRunner(scan.NewScannerFromString("", src), ctx.RuleSet).All()
+
+	f := scan.NewFeeder("", ctx.RuleSet)
+	var have []scan.Token
+	for i := range len(src) {
+		have = append(have, f.Feed([]byte{src[i]})...)
+	}
+	have = append(have, f.Close()...)
+	if !slices.EqualFunc(have, want, scan.Token.Equal) {
+		t.Errorf("\n have: %v \n want: %v", have, want)
+	}
+}
+
+var update = flag.Bool("update", false, "update GRAMMAR.md")
+
+func TestGrammar(t *testing.T) {
+	scan.RunGrammarTest(t, "GRAMMAR.md", "Go Lexical Grammar", NewContext().RuleSet, *update)
+}
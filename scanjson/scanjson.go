@@ -1,6 +1,11 @@
 package scanjson
 
-import "github.com/blackchip-org/scan"
+import (
+	"bytes"
+	"encoding/json"
+
+	"github.com/blackchip-org/scan"
+)
 
 var (
 	EscapeRules = []scan.Rule{
@@ -35,10 +40,23 @@ type Context struct {
 func NewContext() *Context {
 	c := &Context{}
 	c.RuleSet = scan.NewRuleSet(
-		Whitespace,
-		Literals,
-		String,
-		Number,
+		scan.Label(Whitespace, "whitespace"),
+		scan.Label(Literals, "literals"),
+		scan.Label(String, "string"),
+		scan.Label(Number, "number"),
 	)
 	return c
 }
+
+// Encode returns the JSON source text for a token that does not have a
+// literal. It can be used with a scan.Printer.
+func Encode(tok scan.Token) string {
+	if tok.Type != scan.StrType {
+		return tok.Val
+	}
+	var b bytes.Buffer
+	enc := json.NewEncoder(&b)
+	enc.SetEscapeHTML(false)
+	enc.Encode(tok.Val)
+	return string(bytes.TrimSuffix(b.Bytes(), []byte("\n")))
+}
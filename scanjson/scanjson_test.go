@@ -2,6 +2,8 @@ package scanjson
 
 import (
 	_ "embed"
+	"flag"
+	"strings"
 	"testing"
 
 	"github.com/blackchip-org/scan"
@@ -90,3 +92,41 @@ var exampleTokens string
 // 		t.Errorf("have:\n%v\nwant:\n%v", have, exampleTokens)
 // 	}
 // }
+
+func TestRoundTrip(t *testing.T) {
+	ctx := NewContext()
+	s := scan.NewScannerFromString("example.json", example)
+	r := scan.NewRunner(s, ctx.RuleSet, scan.WithTrivia(true))
+
+	var b strings.Builder
+	if err := scan.Print(&b, append(r.All(), r.This)); err != nil {
+		t.Fatal(err)
+	}
+	if have := b.String(); have != example {
+		t.Errorf("have:\n%v\nwant:\n%v", have, example)
+	}
+}
+
+func TestRewrite(t *testing.T) {
+	ctx := NewContext()
+	s := scan.NewScannerFromString("", `{"name": "Dinagat Islands"}`)
+	toks := scan.NewRunner(s, ctx.RuleSet).All()
+	toks[3].Val = "<Dinagat \"Islands\">"
+	toks[3].Lit = ""
+
+	var b strings.Builder
+	p := scan.Printer{Sep: " ", Encode: Encode}
+	if err := p.Print(&b, toks); err != nil {
+		t.Fatal(err)
+	}
+	want := `{"name": "<Dinagat \"Islands\">"}`
+	if have := b.String(); have != want {
+		t.Errorf("\n have: %v \n want: %v", have, want)
+	}
+}
+
+var update = flag.Bool("update", false, "update GRAMMAR.md")
+
+func TestGrammar(t *testing.T) {
+	scan.RunGrammarTest(t, "GRAMMAR.md", "JSON Lexical Grammar", NewContext().RuleSet, *update)
+}
@@ -1,12 +1,14 @@
 package scan
 
 import (
-	"bytes"
+	"context"
+	"encoding/json"
 	"errors"
 	"fmt"
 	"io"
 	"slices"
 	"strings"
+	"unicode/utf8"
 
 	"github.com/blackchip-org/scan/peek"
 )
@@ -31,15 +33,17 @@ const (
 	WordType      = "word"
 )
 
-// Pos represents a position within an input stream.
+// Pos represents a position within an input stream. Offset is the number of
+// bytes from the start of the stream.
 type Pos struct {
-	Name string `json:"name,omitempty"`
-	Line int    `json:"line"`
-	Col  int    `json:"col"`
+	Name   string `json:"name,omitempty"`
+	Line   int    `json:"line"`
+	Col    int    `json:"col"`
+	Offset int    `json:"offset"`
 }
 
-// NewPos returns a new position with the given name and the line number
-// and column number set to one.
+// NewPos returns a new position with the given name, the line number
+// and column number set to one, and the offset set to zero.
 func NewPos(name string) Pos {
 	return Pos{Name: name, Line: 1, Col: 1}
 }
@@ -51,21 +55,129 @@ func (p Pos) String() string {
 	return fmt.Sprintf("%v:%v", p.Line, p.Col)
 }
 
+var (
+	// ErrInvalidMark is the cause of the error reported when a scanner is
+	// reset to a mark that is no longer valid.
+	ErrInvalidMark = errors.New("mark is no longer valid")
+
+	// ErrInvalidRule is the cause of the error reported when a rule has
+	// been configured incorrectly.
+	ErrInvalidRule = errors.New("invalid rule")
+
+	// ErrNotAdvancing is the cause of the error reported when the rules
+	// being evaluated are not consuming any input.
+	ErrNotAdvancing = errors.New("scanner is not advancing")
+)
+
+// ErrorKind describes why an error was reported.
+type ErrorKind int
+
+const (
+	// InvalidInput is the kind of error reported for input that is not
+	// valid.
+	InvalidInput ErrorKind = iota
+
+	// IncompleteInput is the kind of error reported when the input ends
+	// before a token is complete, such as a string that is not terminated.
+	// The input might be valid once more is added.
+	IncompleteInput
+)
+
+func (k ErrorKind) String() string {
+	switch k {
+	case InvalidInput:
+		return "invalid"
+	case IncompleteInput:
+		return "incomplete"
+	}
+	return fmt.Sprintf("ErrorKind(%d)", int(k))
+}
+
+func (k ErrorKind) MarshalText() ([]byte, error) {
+	return []byte(k.String()), nil
+}
+
+// Severity is how serious an error is. The zero value is SeverityError.
+type Severity int
+
+const (
+	SeverityError Severity = iota
+	SeverityWarning
+	SeverityInfo
+)
+
+func (v Severity) String() string {
+	switch v {
+	case SeverityError:
+		return "error"
+	case SeverityWarning:
+		return "warning"
+	case SeverityInfo:
+		return "info"
+	}
+	return fmt.Sprintf("Severity(%d)", int(v))
+}
+
+func (v Severity) MarshalText() ([]byte, error) {
+	return []byte(v.String()), nil
+}
+
+// Error is a problem found in the input. Code is a stable identifier for
+// the type of problem, such as "str.unterminated", that does not change when
+// the message is reworded. End is the position just after the text that
+// caused the error and is the zero value when not known.
 type Error struct {
-	Pos     Pos
-	Message string
-	Cause   error
+	Pos      Pos
+	End      Pos
+	Message  string
+	Cause    error
+	Kind     ErrorKind
+	Severity Severity
+	Code     string
 }
 
 func (e Error) Equal(e2 Error) bool {
-	return e.Pos == e2.Pos && e.Message == e2.Message
+	return e.Pos == e2.Pos &&
+		e.End == e2.End &&
+		e.Message == e2.Message &&
+		e.Kind == e2.Kind &&
+		e.Severity == e2.Severity &&
+		e.Code == e2.Code
 }
 
+// Error returns the position, severity, and message of the error followed
+// by the cause, if any.
 func (e Error) Error() string {
+	return fmt.Sprintf("%v: %v: %v", e.Pos, e.Severity, e.Text())
+}
+
+// MarshalJSON encodes the error with the text of the cause in place of
+// the cause itself. The end position is omitted when not known.
+func (e Error) MarshalJSON() ([]byte, error) {
+	var end *Pos
+	if e.End != (Pos{}) {
+		end = &e.End
+	}
+	return json.Marshal(struct {
+		Severity Severity  `json:"severity"`
+		Code     string    `json:"code,omitempty"`
+		Kind     ErrorKind `json:"kind"`
+		Message  string    `json:"message"`
+		Pos      Pos       `json:"pos"`
+		End      *Pos      `json:"end,omitempty"`
+	}{e.Severity, e.Code, e.Kind, e.Text(), e.Pos, end})
+}
+
+// Text returns the message of the error followed by the cause, if any. If
+// there is no message, the cause is used as the message.
+func (e Error) Text() string {
+	if e.Message == "" {
+		return fmt.Sprint(e.Cause)
+	}
 	if e.Cause != nil {
-		return fmt.Sprintf("%v: error: %v: %v", e.Pos, e.Message, e.Cause)
+		return fmt.Sprintf("%v: %v", e.Message, e.Cause)
 	}
-	return fmt.Sprintf("%v: error: %v", e.Pos, e.Message)
+	return e.Message
 }
 
 func (e Error) Unwrap() error {
@@ -97,12 +209,21 @@ func (es Errors) Error() string {
 	return strings.Join(messages, "\n")
 }
 
+// Token is a value emitted by the scanner. Pos is the position of the first
+// rune in the token and End is the position just after the last rune. The
+// source text of the token is found between Pos.Offset and End.Offset.
+//
+// Leading and Trailing hold the whitespace and comments found before and
+// after the token when a Runner is created with WithTrivia.
 type Token struct {
-	Val  string `json:"val"`
-	Lit  string `json:"lit,omitempty"`
-	Type string `json:"type"`
-	Pos  Pos    `json:"pos"`
-	Errs Errors `json:"errs,omitempty"`
+	Val      string  `json:"val"`
+	Lit      string  `json:"lit,omitempty"`
+	Type     string  `json:"type"`
+	Pos      Pos     `json:"pos"`
+	End      Pos     `json:"end"`
+	Errs     Errors  `json:"errs,omitempty"`
+	Leading  []Token `json:"leading,omitempty"`
+	Trailing []Token `json:"trailing,omitempty"`
 }
 
 func (t Token) IsValid() bool {
@@ -113,12 +234,26 @@ func (t Token) IsEndOfText() bool {
 	return t.Type == EndOfTextType
 }
 
+// IsIncomplete returns true if the token has an error with a kind of
+// IncompleteInput.
+func (t Token) IsIncomplete() bool {
+	for _, err := range t.Errs {
+		if err.Kind == IncompleteInput {
+			return true
+		}
+	}
+	return false
+}
+
 func (t Token) Equal(t2 Token) bool {
 	return t.Val == t2.Val &&
 		t.Lit == t2.Lit &&
 		t.Type == t2.Type &&
 		t.Pos == t2.Pos &&
-		t.Errs.Equal(t2.Errs)
+		t.End == t2.End &&
+		t.Errs.Equal(t2.Errs) &&
+		slices.EqualFunc(t.Leading, t2.Leading, Token.Equal) &&
+		slices.EqualFunc(t.Trailing, t2.Trailing, Token.Equal)
 }
 
 func (t Token) String() string {
@@ -138,18 +273,37 @@ func (t Token) String() string {
 }
 
 type Scanner struct {
-	This    rune
-	Next    rune
-	Val     strings.Builder
-	Lit     strings.Builder
-	Pos     Pos
-	Errs    Errors
-	Type    string
-	src     *peek.Reader
-	srcErr  *Error
-	tokPos  Pos
-	prevPos Pos
-	stalls  int
+	This        rune
+	Next        rune
+	Val         Buffer
+	Lit         Buffer
+	Pos         Pos
+	Errs        Errors
+	Type        string
+	src         *peek.Reader
+	reader      *peek.Reader
+	text        string
+	thisLen     int
+	nextLen     int
+	err         *Error
+	halted      bool
+	tokPos      Pos
+	tokIdx      int
+	prevPos     Pos
+	stalls      int
+	hist        []rune
+	gen         int
+	hold        int
+	modes       []string
+	ctx         context.Context
+	ticks       int
+	maxTokenLen int
+	keepTrivia  bool
+	trivia      []Token
+	state       map[any]any
+	lines       bool
+	suspend     *suspension
+	resumable   bool
 }
 
 func NewScanner(name string, src io.Reader) *Scanner {
@@ -170,27 +324,67 @@ func NewScannerFromBytes(name string, src []byte) *Scanner {
 	return s
 }
 
+// Init initializes the scanner to read from src. When the scanner has read
+// from a reader before, its buffers are reused.
 func (s *Scanner) Init(name string, src io.Reader) {
-	s.This = 0
-	s.Next = 0
-	s.Val.Reset()
-	s.Lit.Reset()
-	s.Errs = nil
-	s.Type = ""
-	s.src = peek.NewReader(src)
-	s.srcErr = nil
-	s.next()
-	s.next()
-	s.Pos = NewPos(name)
-	s.tokPos = NewPos(name)
+	s.InitAt(NewPos(name), src)
 }
 
+// InitAt initializes the scanner to read from src which starts at position
+// pos of a larger input. The positions of the tokens are relative to pos.
+func (s *Scanner) InitAt(pos Pos, src io.Reader) {
+	if s.reader == nil {
+		s.reader = peek.NewReader(src)
+	} else {
+		s.reader.Reset(src)
+	}
+	s.src = s.reader
+	s.text = ""
+	s.init(pos, false)
+}
+
+// InitFromString initializes the scanner to read directly from src. The
+// token values and literals emitted are substrings of src unless their
+// contents differ from the source text.
 func (s *Scanner) InitFromString(name string, src string) {
-	s.Init(name, strings.NewReader(src))
+	s.InitFromStringAt(NewPos(name), src)
+}
+
+// InitFromStringAt initializes the scanner to read directly from src
+// starting at position pos. The offset of pos is the number of bytes from
+// the start of src.
+func (s *Scanner) InitFromStringAt(pos Pos, src string) {
+	s.src = nil
+	s.text = src
+	s.init(pos, true)
 }
 
+// InitFromBytes initializes the scanner to read directly from src. The
+// slice is copied once into a string and the scanner then works the same
+// as with InitFromString.
 func (s *Scanner) InitFromBytes(name string, src []byte) {
-	s.Init(name, bytes.NewReader(src))
+	s.InitFromString(name, string(src))
+}
+
+func (s *Scanner) init(pos Pos, shared bool) {
+	s.Val.init(s.text, shared)
+	s.Lit.init(s.text, shared)
+	s.Errs = nil
+	s.Type = ""
+	s.err = nil
+	s.halted = false
+	s.Pos = pos
+	s.tokPos = s.Pos
+	s.prevPos = Pos{}
+	s.stalls = 0
+	s.hold = 0
+	s.modes = s.modes[:0]
+	s.trivia = s.trivia[:0]
+	clear(s.state)
+	s.lines = false
+	s.suspend = nil
+	s.release()
+	s.fill()
 }
 
 // HasMore return true if there is more data to consume in the stream.
@@ -206,6 +400,9 @@ func (s *Scanner) Peek(i int) rune {
 		return s.Next
 	}
 	if i > 1 {
+		if s.src == nil {
+			return s.peekText(i)
+		}
 		ch, err := s.src.Peek(i - 2 + 1)
 		if err != nil {
 			return EndOfText
@@ -220,12 +417,41 @@ func (s *Scanner) Peek(i int) rune {
 	return chs[li]
 }
 
+// peekRune is the same as Peek for a reader but also returns the size of
+// the rune in bytes. The index i must not be negative.
+func (s *Scanner) peekRune(i int) (rune, int) {
+	switch i {
+	case 0:
+		return s.This, s.thisLen
+	case 1:
+		return s.Next, s.nextLen
+	}
+	ch, size, err := s.src.PeekRune(i - 1)
+	if err != nil {
+		return EndOfText, 0
+	}
+	return ch, size
+}
+
+func (s *Scanner) peekText(i int) rune {
+	off := s.Pos.Offset + s.thisLen + s.nextLen
+	for ; i > 2; i-- {
+		if off >= len(s.text) {
+			return EndOfText
+		}
+		_, width := utf8.DecodeRuneInString(s.text[off:])
+		off += width
+	}
+	ch, _ := s.read(off)
+	return ch
+}
+
 // Keep advances the stream to the next rune and adds the current rune to
 // the token value and literal.
 func (s *Scanner) Keep() {
 	if s.This != EndOfText {
-		s.Val.WriteRune(s.This)
-		s.Lit.WriteRune(s.This)
+		s.Val.add(s.This, s.Pos.Offset, s.thisLen)
+		s.Lit.add(s.This, s.Pos.Offset, s.thisLen)
 		s.next()
 	}
 }
@@ -233,33 +459,32 @@ func (s *Scanner) Keep() {
 // Skip advances the string to the next rune without adding the current rune to
 // the token value. This current rune is written to the literal value.
 func (s *Scanner) Skip() {
-	s.Lit.WriteRune(s.This)
-	s.next()
+	if s.This != EndOfText {
+		s.Lit.add(s.This, s.Pos.Offset, s.thisLen)
+		s.next()
+	}
 }
 
 // Discard advances the string to the next rune without adding the current
-// rune to the token.
+// rune to the token. When trivia is being kept, the rune is saved as
+// trivia instead.
 func (s *Scanner) Discard() {
-	s.next()
-	s.Emit()
+	if !s.keepTrivia {
+		s.next()
+		s.Emit()
+		return
+	}
+	s.Skip()
+	s.addTrivia(s.Emit())
 }
 
+// Undo places the token literal seen so far back onto the input stream and
+// clears the token buffer.
 func (s *Scanner) Undo() {
-	chs := []rune(s.Lit.String())
-	slices.Reverse(chs)
-	for _, ch := range chs {
-		if s.Next != EndOfText {
-			s.src.Unread(s.Next)
-		}
-		if s.This != EndOfText {
-			s.Next = s.This
-		}
-		s.This = ch
-	}
+	s.rewind(s.tokPos, s.tokIdx)
 	s.Val.Reset()
 	s.Lit.Reset()
 	s.Type = ""
-	s.Pos = s.tokPos
 }
 
 // Emit returns the token that has been built and resets the builder for the
@@ -267,27 +492,33 @@ func (s *Scanner) Undo() {
 func (s *Scanner) Emit() Token {
 	var t Token
 
-	if s.tokPos == s.prevPos {
+	if s.This != EndOfText && s.tokPos == s.prevPos {
 		s.stalls++
-		if s.stalls > 10 {
-			s.prevPos = Pos{}
-			s.stalls = 0
-			panic("scanner is not advancing")
-		}
 	} else {
 		s.stalls = 0
 	}
+	s.prevPos = s.tokPos
+	if s.stalls > 10 {
+		s.stalls = 0
+		s.halt(Error{
+			Pos:   s.tokPos,
+			Cause: ErrNotAdvancing,
+		})
+	}
 
 	t.Val = s.Val.String()
 	t.Lit = s.Lit.String()
 	t.Type = s.Type
 	t.Pos = s.tokPos
+	t.End = s.Pos
 	t.Errs = s.Errs
 
-	if t.Lit == "" && s.srcErr != nil {
+	// Once the end of the stream has been reached, report the error that
+	// stopped the scanner, if any.
+	if t.Lit == "" && s.This == EndOfText && s.err != nil {
 		t.Type = ErrorType
-		t.Errs = append(t.Errs, *s.srcErr)
-		return t
+		t.Errs = append(t.Errs, *s.err)
+		s.err = nil
 	}
 
 	// If there are no type yet, set it to the value
@@ -297,7 +528,7 @@ func (s *Scanner) Emit() Token {
 
 	// If the no token value was generated and the current rune show an end of
 	// stream conditions, we are done
-	if t.Val == "" && t.Lit == "" && s.This == EndOfText {
+	if t.Val == "" && t.Lit == "" && s.This == EndOfText && t.Type != ErrorType {
 		t.Type = EndOfTextType
 	}
 
@@ -306,6 +537,10 @@ func (s *Scanner) Emit() Token {
 	s.Type = ""
 	s.Errs = nil
 	s.tokPos = s.Pos
+	if s.hold == 0 {
+		s.release()
+	}
+	s.tokIdx = len(s.hist)
 
 	return t
 }
@@ -318,13 +553,64 @@ func (s *Scanner) Eval(r Rule) (Token, bool) {
 }
 
 func (s *Scanner) Illegal(format string, args ...any) {
+	s.illegal(InvalidInput, "", format, args...)
+}
+
+// Incomplete is like Illegal but marks the error as an IncompleteInput.
+// Rules call this when the end of the input is reached before the token
+// is complete.
+func (s *Scanner) Incomplete(format string, args ...any) {
+	s.illegal(IncompleteInput, "", format, args...)
+}
+
+func (s *Scanner) illegal(kind ErrorKind, code string, format string, args ...any) {
+	s.illegalAt(Pos{}, kind, code, format, args...)
+}
+
+// illegalAt reports an error for the text from pos up to the current
+// position. When pos is not set, the error is reported at the current
+// position without an end.
+func (s *Scanner) illegalAt(pos Pos, kind ErrorKind, code string, format string, args ...any) {
+	// A token cut short by a halt is not incomplete as the input did not
+	// actually end.
+	if kind == IncompleteInput && s.halted {
+		return
+	}
+	var end Pos
+	if pos.Line != 0 {
+		end = s.Pos
+	}
 	s.Type = IllegalType
-	s.Errs = append(s.Errs, Error{
-		Pos:     s.Pos,
+	s.Report(Error{
+		Pos:     pos,
+		End:     end,
 		Message: fmt.Sprintf(format, args...),
+		Kind:    kind,
+		Code:    code,
 	})
 }
 
+// Report adds e to the errors of the token being scanned. If the position
+// of e has not been set, the current position is used. Unlike Illegal, the
+// type of the token is not changed, which is useful for warnings.
+func (s *Scanner) Report(e Error) {
+	if e.Pos.Line == 0 {
+		e.Pos = s.Pos
+	}
+	s.Errs = append(s.Errs, e)
+}
+
+// halt stops the scanner as if the end of the stream has been reached. The
+// error is reported with the next token emitted that has an empty literal.
+// The scanner stays at the end of the stream, even when rewound, until it
+// is initialized again.
+func (s *Scanner) halt(err Error) {
+	s.This, s.Next = EndOfText, EndOfText
+	s.thisLen, s.nextLen = 0, 0
+	s.err = &err
+	s.halted = true
+}
+
 func (s *Scanner) next() {
 	if s.This == EndOfText {
 		return
@@ -335,21 +621,92 @@ func (s *Scanner) next() {
 	} else {
 		s.Pos.Col++
 	}
+	s.Pos.Offset += s.thisLen
+	if s.src != nil {
+		s.hist = append(s.hist, held(s.This, s.thisLen))
+	}
+
+	s.This, s.thisLen = s.Next, s.nextLen
+	if s.This != EndOfText {
+		s.Next, s.nextLen = s.read(s.Pos.Offset + s.thisLen)
+	}
+	s.checkLimits()
+}
 
-	var err error
-	s.This = s.Next
-	s.Next, err = s.src.Read()
+// checkLimits halts the scanner when the token being scanned is too long or
+// when the context is done. The context is only checked every so often as
+// it can be expensive.
+func (s *Scanner) checkLimits() {
+	if s.maxTokenLen > 0 && s.Pos.Offset-s.tokPos.Offset > s.maxTokenLen {
+		s.halt(Error{
+			Pos:   s.tokPos,
+			End:   s.Pos,
+			Cause: ErrMaxTokenLen,
+		})
+		return
+	}
+	if s.ctx != nil {
+		s.ticks++
+		if s.ticks%1024 != 0 {
+			return
+		}
+		if err := s.ctx.Err(); err != nil {
+			s.halt(Error{
+				Pos:   s.Pos,
+				Cause: err,
+			})
+		}
+	}
+}
 
+// fill loads This and Next with the runes found at the current position.
+func (s *Scanner) fill() {
+	if s.err != nil || s.halted {
+		s.This, s.thisLen = EndOfText, 0
+		s.Next, s.nextLen = EndOfText, 0
+		return
+	}
+	s.This, s.thisLen = s.read(s.Pos.Offset)
+	if s.This == EndOfText {
+		s.Next, s.nextLen = EndOfText, 0
+		return
+	}
+	s.Next, s.nextLen = s.read(s.Pos.Offset + s.thisLen)
+}
+
+// read returns the rune found at offset off and its length in bytes. The
+// offset is only used when reading from memory; otherwise the next rune
+// from the reader is returned.
+func (s *Scanner) read(off int) (rune, int) {
+	if s.src == nil {
+		if off >= len(s.text) {
+			return EndOfText, 0
+		}
+		return utf8.DecodeRuneInString(s.text[off:])
+	}
+
+	ch, size, err := s.src.ReadRune()
 	if err != nil {
 		// Mark the stream as done when seeing an EOF but don't retain that
 		// as an actual error
 		if !errors.Is(err, io.EOF) {
-			s.srcErr = &Error{
+			s.err = &Error{
 				Pos:     s.Pos,
 				Message: fmt.Sprintf("error reading stream: %v", err),
 				Cause:   err,
 			}
 		}
-		s.Next = EndOfText
+		return EndOfText, 0
+	}
+	return ch, size
+}
+
+// held returns the rune to push back onto the reader for ch which was
+// read with a size of n bytes. A byte that is not a valid encoding is
+// pushed back as peek.Invalid so that it keeps its size when read again.
+func held(ch rune, n int) rune {
+	if ch == utf8.RuneError && n == 1 {
+		return peek.Invalid
 	}
+	return ch
 }
@@ -1,8 +1,13 @@
 package scan
 
 import (
+	"errors"
 	"strconv"
+	"strings"
 	"testing"
+	"unsafe"
+
+	"github.com/blackchip-org/scan/iotest"
 )
 
 func TestKeep(t *testing.T) {
@@ -75,6 +80,7 @@ func TestEmpty(t *testing.T) {
 	s.Keep()
 	want := Token{
 		Pos:  Pos{Line: 1, Col: 1},
+		End:  Pos{Line: 1, Col: 1},
 		Type: EndOfTextType,
 	}
 	tok := s.Emit()
@@ -90,7 +96,8 @@ func TestEnd(t *testing.T) {
 	Repeat(s.Keep, 10)
 
 	want := Token{
-		Pos:  Pos{Line: 1, Col: 2},
+		Pos:  Pos{Line: 1, Col: 2, Offset: 1},
+		End:  Pos{Line: 1, Col: 2, Offset: 1},
 		Type: EndOfTextType,
 	}
 	tok := s.Emit()
@@ -108,7 +115,7 @@ func TestReInit(t *testing.T) {
 	if have.Val != want {
 		t.Errorf("\n have: %v \n want: %v", have, want)
 	}
-	wantPos := Pos{Name: "foo", Line: 1, Col: 2}
+	wantPos := Pos{Name: "foo", Line: 1, Col: 2, Offset: 1}
 	if have.Pos != wantPos {
 		t.Errorf("\n have: %v \n want: %v", have.Pos, wantPos)
 	}
@@ -121,7 +128,7 @@ func TestReInit(t *testing.T) {
 	if have.Val != want {
 		t.Errorf("\n have: %v \n want: %v", have, want)
 	}
-	wantPos = Pos{Name: "bar", Line: 1, Col: 2}
+	wantPos = Pos{Name: "bar", Line: 1, Col: 2, Offset: 1}
 	if have.Pos != wantPos {
 		t.Errorf("\n have: %v \n want: %v", have.Pos, wantPos)
 	}
@@ -139,16 +146,179 @@ func TestInit(t *testing.T) {
 	}
 }
 
+func TestOffsets(t *testing.T) {
+	src := "ab 日本\nx€y"
+	s := NewScannerFromString("", src)
+	rules := NewRuleSet(SkipSpaceRule, NewWhileRule(Not(IsSpace), WordType))
+	toks := NewRunner(s, rules).All()
+	tests := []struct {
+		lit string
+		pos Pos
+		end Pos
+	}{
+		{"ab", Pos{Line: 1, Col: 1, Offset: 0}, Pos{Line: 1, Col: 3, Offset: 2}},
+		{"日本", Pos{Line: 1, Col: 4, Offset: 3}, Pos{Line: 1, Col: 6, Offset: 9}},
+		{"x€y", Pos{Line: 2, Col: 1, Offset: 10}, Pos{Line: 2, Col: 4, Offset: 15}},
+	}
+	if len(toks) != len(tests) {
+		t.Fatalf("\n have: %v tokens \n want: %v tokens", len(toks), len(tests))
+	}
+	for i, test := range tests {
+		tok := toks[i]
+		if tok.Pos != test.pos || tok.End != test.end {
+			t.Errorf("\n have: %v-%v \n want: %v-%v", tok.Pos, tok.End, test.pos, test.end)
+		}
+		if have := src[tok.Pos.Offset:tok.End.Offset]; have != test.lit {
+			t.Errorf("\n have: %v \n want: %v", have, test.lit)
+		}
+	}
+}
+
+func TestUndoOffset(t *testing.T) {
+	s := NewScannerFromString("", "a\nbc")
+	s.Keep()
+	s.Keep()
+	s.Keep()
+	s.Undo()
+	want := Pos{Line: 1, Col: 1, Offset: 0}
+	if s.Pos != want {
+		t.Errorf("\n have: %v \n want: %v", s.Pos, want)
+	}
+	While(s, IsAny, s.Keep)
+	tok := s.Emit()
+	wantEnd := Pos{Line: 2, Col: 3, Offset: 4}
+	if tok.End != wantEnd {
+		t.Errorf("\n have: %v \n want: %v", tok.End, wantEnd)
+	}
+}
+
 func TestNotAdvancing(t *testing.T) {
 	var s Scanner
 
-	defer func() {
-		if r := recover(); r == nil {
-			t.Errorf("expected panic")
+	var toks []Token
+	for i := 0; i < 100; i++ {
+		tok := s.Emit()
+		if tok.IsEndOfText() {
+			break
 		}
-	}()
+		toks = append(toks, tok)
+	}
+	last := toks[len(toks)-1]
+	if last.Type != ErrorType || !errors.Is(last.Errs[0], ErrNotAdvancing) {
+		t.Errorf("\n have: %v \n want: %v", last, ErrNotAdvancing)
+	}
+}
 
-	for i := 0; i < 100; i++ {
-		s.Emit()
+func TestReadError(t *testing.T) {
+	r := iotest.NewReader(strings.NewReader("abc def"))
+	r.Limit = 5
+	r.Err = errors.New("test error")
+
+	s := NewScanner("", r)
+	toks := NewRunner(s, NewRuleSet(SkipSpaceRule, WordRule)).All()
+	want := []string{WordType, WordType, ErrorType}
+	if len(toks) != len(want) {
+		t.Fatalf("\n have: %v \n want: %v", toks, want)
+	}
+	for i, tok := range toks {
+		if tok.Type != want[i] {
+			t.Errorf("\n have: %v \n want: %v", tok.Type, want[i])
+		}
+	}
+	if !errors.Is(toks[2].Errs[0], r.Err) {
+		t.Errorf("\n have: %v \n want: %v", toks[2].Errs, r.Err)
+	}
+}
+
+func TestBackends(t *testing.T) {
+	rules := NewRuleSet(
+		SkipSpaceRule,
+		StandardIdentRule,
+		SignedRealExpRule.WithDigitSep(Rune('_')),
+		StrDoubleQuoteRule.WithEscapeRules(NewCharEncRule(LineFeedEnc), Hex4EncRule),
+		Literal("+", "-", "*", "/", "(", ")", "\uFFFD\uFFFD"),
+		NewRegexRule(`[\x{FFFD}x]+y`, "repl"),
+	)
+	srcs := []string{
+		`foo + 1_000.5e-3 * ("bar\n" - "\u65e5本")`,
+		"abc\n\t 123 \xff 日本語 -",
+		"a \uFFFD b",
+		"\xff\uFFFD\xfe\uFFFD\uFFFD + \uFFFDx\xffy x\uFFFDy \uFFFDx \"\xff\uFFFD\"",
+		`"not terminated`,
+		"",
+	}
+	for _, src := range srcs {
+		t.Run(src, func(t *testing.T) {
+			have := NewRunner(NewScannerFromString("", src), rules).All()
+			want := NewRunner(NewScanner("", strings.NewReader(src)), rules).All()
+			if len(have) != len(want) {
+				t.Fatalf("\n have: %v \n want: %v", have, want)
+			}
+			for i := range have {
+				if !have[i].Equal(want[i]) {
+					t.Errorf("\n have: %v \n want: %v", have[i], want[i])
+				}
+			}
+		})
+	}
+}
+
+func TestZeroCopy(t *testing.T) {
+	src := `abc "def" 1_000`
+	rules := NewRuleSet(SkipSpaceRule, StandardIdentRule, StrDoubleQuoteRule,
+		IntRule.WithDigitSep(Rune('_')))
+	toks := NewRunner(NewScannerFromString("", src), rules).All()
+
+	inSrc := func(v string) bool {
+		p := uintptr(unsafe.Pointer(unsafe.StringData(v)))
+		start := uintptr(unsafe.Pointer(unsafe.StringData(src)))
+		return p >= start && p < start+uintptr(len(src))
+	}
+	tests := []struct {
+		val    string
+		valSrc bool
+		litSrc bool
+	}{
+		{"abc", true, true},
+		{"def", true, true},
+		{"1000", false, true},
+	}
+	for i, test := range tests {
+		tok := toks[i]
+		if tok.Val != test.val {
+			t.Fatalf("\n have: %v \n want: %v", tok.Val, test.val)
+		}
+		if inSrc(tok.Val) != test.valSrc {
+			t.Errorf("%v: val in source: %v", tok.Val, !test.valSrc)
+		}
+		if inSrc(tok.Lit) != test.litSrc {
+			t.Errorf("%v: lit in source: %v", tok.Val, !test.litSrc)
+		}
+	}
+}
+
+func TestPeekString(t *testing.T) {
+	s := NewScannerFromString("", "a日b€c")
+	want := []rune{'a', '日', 'b', '€', 'c', EndOfText, EndOfText}
+	for i, ch := range want {
+		if have := s.Peek(i); have != ch {
+			t.Errorf("%v: \n have: %c \n want: %c", i, have, ch)
+		}
+	}
+}
+
+func TestState(t *testing.T) {
+	type key struct{}
+	s := NewScannerFromString("", "abc")
+	if v := s.State(key{}); v != nil {
+		t.Fatalf("unexpected state: %v", v)
+	}
+	s.SetState(key{}, 42)
+	if v := s.State(key{}); v != 42 {
+		t.Fatalf("\n have: %v \n want: 42", v)
+	}
+	s.InitFromString("", "def")
+	if v := s.State(key{}); v != nil {
+		t.Errorf("state not cleared: %v", v)
 	}
 }
@@ -0,0 +1,476 @@
+// Package spec builds rule sets from lexer definitions written in JSON.
+//
+// A spec lists the rules to try in order. Each rule names one of the rules
+// found in package scan and gives its options:
+//
+//	{
+//	    "name": "calc",
+//	    "rules": [
+//	        {"rule": "while", "class": "IsSpace", "keep": false},
+//	        {"rule": "comment", "begin": "#", "end": "\n"},
+//	        {"rule": "num", "digits": "IsDigit09", "decSep": {"runes": "."}},
+//	        {"rule": "str", "begin": "\"", "end": "\"", "escape": "\\"},
+//	        {"rule": "ident", "head": "IsLetterUnder", "tail": "IsLetterDigitUnder",
+//	            "keywords": ["let"]},
+//	        {"rule": "literal", "values": ["+", "-", "*", "/", "=", "(", ")"]}
+//	    ]
+//	}
+package spec
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"unicode/utf8"
+
+	"github.com/blackchip-org/scan"
+)
+
+// Spec is the definition of a lexer.
+type Spec struct {
+	// Name of the language being scanned.
+	Name string `json:"name,omitempty"`
+
+	// Rules are evaluated in order for each token.
+	Rules []Rule `json:"rules"`
+
+	// Modes are the rules used when the named mode is on top of the mode
+	// stack. Use a mode rule to push and pop modes.
+	Modes map[string][]Rule `json:"modes,omitempty"`
+
+	// LongestMatch uses the rule that matches the most input instead of the
+	// first rule that matches.
+	LongestMatch bool `json:"longestMatch,omitempty"`
+
+	// Semicolons inserts semicolons at the end of lines when set.
+	Semicolons *Semicolons `json:"semicolons,omitempty"`
+}
+
+// Semicolons configures scan.InsertSemicolons.
+type Semicolons struct {
+	Newline string   `json:"newline"`
+	After   []string `json:"after"`
+}
+
+// Rule is the definition of one rule. The kind of rule is given by the
+// Rule field and only the fields used by that kind should be set:
+//
+//   - class: Class, Type
+//   - comment: Begin, End, Keep
+//   - ident: Head, Tail, Keywords
+//   - literal: Values
+//   - mode: Match, Push, Pop
+//   - num: Digits, IntType, RealType, Prefix, Sign, DigitSep, DecSep, Exp,
+//     ExpSign, Suffix, LeadingDigitSep, LeadingZero, EmptyParts
+//   - regex: Pattern, Type
+//   - str: Begin, End, Type, Escape, Escapes, Multiline, MaxLen,
+//     OptionalTerminator, Nesting
+//   - while: Class, Type, Keep
+//
+// The escapes of a string use these kinds:
+//
+//   - charEnc: Map
+//   - hexEnc: Flag, Width, AsByte
+//   - octEnc
+type Rule struct {
+	Rule string `json:"rule"`
+	Type string `json:"type,omitempty"`
+	Keep *bool  `json:"keep,omitempty"`
+
+	Class *Class `json:"class,omitempty"`
+
+	Begin              string `json:"begin,omitempty"`
+	End                string `json:"end,omitempty"`
+	Escape             string `json:"escape,omitempty"`
+	Escapes            []Rule `json:"escapes,omitempty"`
+	Multiline          bool   `json:"multiline,omitempty"`
+	MaxLen             uint   `json:"maxLen,omitempty"`
+	OptionalTerminator bool   `json:"optionalTerminator,omitempty"`
+	Nesting            bool   `json:"nesting,omitempty"`
+
+	Digits          *Class   `json:"digits,omitempty"`
+	IntType         string   `json:"intType,omitempty"`
+	RealType        string   `json:"realType,omitempty"`
+	Prefix          []string `json:"prefix,omitempty"`
+	Sign            *Class   `json:"sign,omitempty"`
+	DigitSep        *Class   `json:"digitSep,omitempty"`
+	DecSep          *Class   `json:"decSep,omitempty"`
+	Exp             *Class   `json:"exp,omitempty"`
+	ExpSign         *Class   `json:"expSign,omitempty"`
+	Suffix          []Rule   `json:"suffix,omitempty"`
+	LeadingDigitSep bool     `json:"leadingDigitSep,omitempty"`
+	LeadingZero     *bool    `json:"leadingZero,omitempty"`
+	EmptyParts      *bool    `json:"emptyParts,omitempty"`
+
+	Head     *Class   `json:"head,omitempty"`
+	Tail     *Class   `json:"tail,omitempty"`
+	Keywords []string `json:"keywords,omitempty"`
+
+	Values []string `json:"values,omitempty"`
+
+	Pattern string `json:"pattern,omitempty"`
+
+	Match *Rule  `json:"match,omitempty"`
+	Push  string `json:"push,omitempty"`
+	Pop   bool   `json:"pop,omitempty"`
+
+	Map    map[string]string `json:"map,omitempty"`
+	Flag   string            `json:"flag,omitempty"`
+	Width  int               `json:"width,omitempty"`
+	AsByte bool              `json:"asByte,omitempty"`
+}
+
+// Class is the definition of a character class. In JSON, a class is either
+// a string with the name of a class predefined in package scan, such as
+// "IsDigit09", or an object with one of the other fields set:
+//
+//	{"runes": "+-"}
+//	{"range": "az"}
+//	{"or": ["IsLetter", {"runes": "_"}]}
+//	{"not": {"runes": "\""}}
+type Class struct {
+	Name  string  `json:"name,omitempty"`
+	Runes string  `json:"runes,omitempty"`
+	Range string  `json:"range,omitempty"`
+	Or    []Class `json:"or,omitempty"`
+	Not   *Class  `json:"not,omitempty"`
+}
+
+func (c *Class) UnmarshalJSON(data []byte) error {
+	if bytes.HasPrefix(bytes.TrimSpace(data), []byte(`"`)) {
+		*c = Class{}
+		return json.Unmarshal(data, &c.Name)
+	}
+	type class Class
+	return json.Unmarshal(data, (*class)(c))
+}
+
+func (c Class) MarshalJSON() ([]byte, error) {
+	if c.Name != "" {
+		return json.Marshal(c.Name)
+	}
+	type class Class
+	return json.Marshal(class(c))
+}
+
+// Classes are the predefined classes that can be referenced by name.
+var Classes = map[string]scan.Class{
+	"IsAny":              scan.IsAny,
+	"IsCurrency":         scan.IsCurrency,
+	"IsDigit":            scan.IsDigit,
+	"IsDigit01":          scan.IsDigit01,
+	"IsDigit07":          scan.IsDigit07,
+	"IsDigit09":          scan.IsDigit09,
+	"IsDigit0F":          scan.IsDigit0F,
+	"IsLetter":           scan.IsLetter,
+	"IsLetterAZ":         scan.IsLetterAZ,
+	"IsLetterUnder":      scan.IsLetterUnder,
+	"IsLetterDigitUnder": scan.IsLetterDigitUnder,
+	"IsNone":             scan.IsNone,
+	"IsPrintable":        scan.IsPrintable,
+	"IsRune8":            scan.IsRune8,
+	"IsRune16":           scan.IsRune16,
+	"IsSign":             scan.IsSign,
+	"IsSpace":            scan.IsSpace,
+}
+
+// ErrInvalidSpec is the cause of the errors returned when a spec cannot be
+// used to build a rule set.
+var ErrInvalidSpec = errors.New("invalid spec")
+
+// Parse decodes a spec from JSON. Unknown fields are an error.
+func Parse(data []byte) (*Spec, error) {
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.DisallowUnknownFields()
+	var s Spec
+	if err := dec.Decode(&s); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSpec, err)
+	}
+	return &s, nil
+}
+
+// Load reads and decodes the spec found in the file at path.
+func Load(path string) (*Spec, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	return Parse(data)
+}
+
+// RuleSet builds the rule set described by the spec. An error is returned
+// if the spec is not valid or if any rule that it builds is not valid.
+func (s *Spec) RuleSet() (scan.RuleSet, error) {
+	rules, err := buildRules(s.Rules, "rules")
+	if err != nil {
+		return scan.RuleSet{}, err
+	}
+	rs := scan.NewRuleSet(rules...).WithLongestMatch(s.LongestMatch)
+	for name, mrules := range s.Modes {
+		rules, err := buildRules(mrules, "modes."+name)
+		if err != nil {
+			return scan.RuleSet{}, err
+		}
+		rs = rs.WithMode(name, scan.NewRuleSet(rules...).WithLongestMatch(s.LongestMatch))
+	}
+	if s.Semicolons != nil {
+		rs = rs.WithPostTokenFunc(scan.InsertSemicolons(s.Semicolons.Newline, s.Semicolons.After...))
+	}
+	if err := rs.Validate(); err != nil {
+		return scan.RuleSet{}, err
+	}
+	return rs, nil
+}
+
+func buildRules(specs []Rule, path string) ([]scan.Rule, error) {
+	rules, err := buildList(specs, path)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidSpec, err)
+	}
+	return rules, nil
+}
+
+// buildList builds each rule in specs. Errors are not wrapped with
+// ErrInvalidSpec so that nested rules are only wrapped once.
+func buildList(specs []Rule, path string) ([]scan.Rule, error) {
+	var rules []scan.Rule
+	for i, spec := range specs {
+		rule, err := spec.Build()
+		if err != nil {
+			return nil, fmt.Errorf("%v[%v]: %v", path, i, err)
+		}
+		rules = append(rules, rule)
+	}
+	return rules, nil
+}
+
+// Build returns the rule described by r.
+func (r Rule) Build() (scan.Rule, error) {
+	switch r.Rule {
+	case "class":
+		c, err := r.Class.build("class")
+		if err != nil {
+			return nil, err
+		}
+		return scan.NewClassRule(c).WithType(r.Type), nil
+	case "comment":
+		if r.Begin == "" || r.End == "" {
+			return nil, errors.New("comment: begin and end are required")
+		}
+		rule := scan.NewCommentRule(scan.Literal(r.Begin), scan.Literal(r.End))
+		if r.Keep != nil {
+			rule = rule.WithKeep(*r.Keep)
+		}
+		return rule, nil
+	case "ident":
+		head, err := r.Head.build("head")
+		if err != nil {
+			return nil, err
+		}
+		tail, err := r.Tail.build("tail")
+		if err != nil {
+			return nil, err
+		}
+		return scan.NewIdentRule(head, tail).WithKeywords(r.Keywords...), nil
+	case "literal":
+		if len(r.Values) == 0 {
+			return nil, errors.New("literal: values are required")
+		}
+		return scan.Literal(r.Values...), nil
+	case "mode":
+		if r.Match == nil {
+			return nil, errors.New("mode: match is required")
+		}
+		match, err := r.Match.Build()
+		if err != nil {
+			return nil, err
+		}
+		rule := scan.NewModeRule(match)
+		if r.Push != "" {
+			rule = rule.WithPush(r.Push)
+		}
+		return rule.WithPop(r.Pop), nil
+	case "num":
+		return r.buildNum()
+	case "regex":
+		return scan.NewRegexRule(r.Pattern, r.Type), nil
+	case "str":
+		return r.buildStr()
+	case "while":
+		c, err := r.Class.build("class")
+		if err != nil {
+			return nil, err
+		}
+		rule := scan.NewWhileRule(c, r.Type)
+		if r.Keep != nil {
+			rule = rule.WithKeep(*r.Keep)
+		}
+		return rule, nil
+	case "":
+		return nil, errors.New("rule is required")
+	}
+	return nil, fmt.Errorf("unknown rule: %v", scan.Quote(r.Rule))
+}
+
+func (r Rule) buildNum() (scan.Rule, error) {
+	digits, err := r.Digits.build("digits")
+	if err != nil {
+		return nil, err
+	}
+	rule := scan.NewNumRule(digits)
+	if r.IntType != "" {
+		rule = rule.WithIntType(r.IntType)
+	}
+	if r.RealType != "" {
+		rule = rule.WithRealType(r.RealType)
+	}
+	if len(r.Prefix) > 0 {
+		rule = rule.WithPrefix(scan.Literal(r.Prefix...))
+	}
+	opts := []struct {
+		c    *Class
+		name string
+		with func(scan.Class) scan.NumRule
+	}{
+		{r.Sign, "sign", func(c scan.Class) scan.NumRule { return rule.WithSign(c) }},
+		{r.DigitSep, "digitSep", func(c scan.Class) scan.NumRule { return rule.WithDigitSep(c) }},
+		{r.DecSep, "decSep", func(c scan.Class) scan.NumRule { return rule.WithDecSep(c) }},
+		{r.Exp, "exp", func(c scan.Class) scan.NumRule { return rule.WithExp(c) }},
+		{r.ExpSign, "expSign", func(c scan.Class) scan.NumRule { return rule.WithExpSign(c) }},
+	}
+	for _, opt := range opts {
+		if opt.c == nil {
+			continue
+		}
+		c, err := opt.c.build(opt.name)
+		if err != nil {
+			return nil, err
+		}
+		rule = opt.with(c)
+	}
+	if len(r.Suffix) > 0 {
+		suffix, err := buildList(r.Suffix, "suffix")
+		if err != nil {
+			return nil, err
+		}
+		rule = rule.WithSuffix(suffix...)
+	}
+	rule = rule.WithLeadingDigitSepAllowed(r.LeadingDigitSep)
+	if r.LeadingZero != nil {
+		rule = rule.WithLeadingZeroAllowed(*r.LeadingZero)
+	}
+	if r.EmptyParts != nil {
+		rule = rule.WithEmptyPartsAllowed(*r.EmptyParts)
+	}
+	return rule, nil
+}
+
+func (r Rule) buildStr() (scan.Rule, error) {
+	begin, err := oneRune("begin", r.Begin)
+	if err != nil {
+		return nil, err
+	}
+	end, err := oneRune("end", r.End)
+	if err != nil {
+		return nil, err
+	}
+	rule := scan.NewStrRule(begin, end).
+		WithType(r.Type).
+		WithMultiline(r.Multiline).
+		WithMaxLen(r.MaxLen).
+		WithOptionalTerminator(r.OptionalTerminator).
+		WithNesting(r.Nesting)
+	if r.Escape != "" {
+		escape, err := oneRune("escape", r.Escape)
+		if err != nil {
+			return nil, err
+		}
+		rule = rule.WithEscape(escape)
+	}
+	var escapes []scan.Rule
+	for i, e := range r.Escapes {
+		esc, err := e.buildEscape()
+		if err != nil {
+			return nil, fmt.Errorf("escapes[%v]: %v", i, err)
+		}
+		escapes = append(escapes, esc)
+	}
+	return rule.WithEscapeRules(escapes...), nil
+}
+
+func (r Rule) buildEscape() (scan.Rule, error) {
+	switch r.Rule {
+	case "charEnc":
+		var encs []scan.CharEnc
+		for from, to := range r.Map {
+			f, err := oneRune("map key", from)
+			if err != nil {
+				return nil, err
+			}
+			t, err := oneRune("map value", to)
+			if err != nil {
+				return nil, err
+			}
+			encs = append(encs, scan.NewCharEnc(f, t))
+		}
+		return scan.NewCharEncRule(encs...), nil
+	case "hexEnc":
+		flag, err := oneRune("flag", r.Flag)
+		if err != nil {
+			return nil, err
+		}
+		return scan.NewHexEncRule(flag, r.Width).AsByte(r.AsByte), nil
+	case "octEnc":
+		return scan.NewOctEncRule(), nil
+	}
+	return nil, fmt.Errorf("unknown escape rule: %v", scan.Quote(r.Rule))
+}
+
+func (c *Class) build(field string) (scan.Class, error) {
+	if c == nil {
+		return nil, fmt.Errorf("%v is required", field)
+	}
+	switch {
+	case c.Name != "":
+		class, ok := Classes[c.Name]
+		if !ok {
+			return nil, fmt.Errorf("%v: unknown class: %v", field, scan.Quote(c.Name))
+		}
+		return class, nil
+	case c.Runes != "":
+		return scan.Rune([]rune(c.Runes)...), nil
+	case c.Range != "":
+		rs := []rune(c.Range)
+		if len(rs) != 2 {
+			return nil, fmt.Errorf("%v: range must have two runes: %v", field, scan.Quote(c.Range))
+		}
+		return scan.Range(rs[0], rs[1]), nil
+	case len(c.Or) > 0:
+		var cs []scan.Class
+		for i := range c.Or {
+			class, err := c.Or[i].build(field)
+			if err != nil {
+				return nil, err
+			}
+			cs = append(cs, class)
+		}
+		return scan.Or(cs...), nil
+	case c.Not != nil:
+		class, err := c.Not.build(field)
+		if err != nil {
+			return nil, err
+		}
+		return scan.Not(class), nil
+	}
+	return nil, fmt.Errorf("%v: empty class", field)
+}
+
+func oneRune(field string, s string) (rune, error) {
+	ch, size := utf8.DecodeRuneInString(s)
+	if s == "" || size != len(s) {
+		return 0, fmt.Errorf("%v must be a single rune: %v", field, scan.Quote(s))
+	}
+	return ch, nil
+}
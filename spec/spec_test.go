@@ -0,0 +1,179 @@
+package spec
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"testing"
+
+	"github.com/blackchip-org/scan"
+	"github.com/blackchip-org/scan/scango"
+	"github.com/blackchip-org/scan/scanjson"
+)
+
+func load(t *testing.T, path string) scan.RuleSet {
+	t.Helper()
+	s, err := Load(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, err := s.RuleSet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return rs
+}
+
+func compare(t *testing.T, name string, src string, have scan.RuleSet, want scan.RuleSet) {
+	t.Helper()
+	htoks := scan.NewRunner(scan.NewScannerFromString(name, src), have).All()
+	wtoks := scan.NewRunner(scan.NewScannerFromString(name, src), want).All()
+	if len(htoks) != len(wtoks) {
+		t.Fatalf("have %v tokens, want %v", len(htoks), len(wtoks))
+	}
+	for i := range htoks {
+		h, w := htoks[i], wtoks[i]
+		if h.Val != w.Val || h.Lit != w.Lit || h.Type != w.Type || h.Pos != w.Pos ||
+			fmt.Sprint(h.Errs) != fmt.Sprint(w.Errs) {
+			t.Fatalf("\n have: %v \n want: %v", h, w)
+		}
+	}
+}
+
+func TestGo(t *testing.T) {
+	rs := load(t, "testdata/go.json")
+	want := scango.NewContext().RuleSet
+	src, err := os.ReadFile("../scango/scango.go")
+	if err != nil {
+		t.Fatal(err)
+	}
+	compare(t, "scango.go", string(src), rs, want)
+	compare(t, "", "x := 0x1p-2i + 0b_1 + 'a' + '\\x80' + \"\\x80\\u00e9\" + `a\nb`\n'ab' \"\\q\" 1__2 $", rs, want)
+}
+
+func TestJSON(t *testing.T) {
+	rs := load(t, "testdata/json.json")
+	want := scanjson.NewContext().RuleSet
+	src, err := os.ReadFile("../scanjson/example.json")
+	if err != nil {
+		t.Fatal(err)
+	}
+	compare(t, "example.json", string(src), rs, want)
+	compare(t, "", `[-0, 01, 1., .5, -1e+5, "\u00e9\n", "\x", tru]`, rs, want)
+}
+
+func TestLeadingZero(t *testing.T) {
+	rs := load(t, "testdata/num.json")
+	want := scan.NewRuleSet(
+		scan.NewWhileRule(scan.Rune(' ', '\n'), "").WithKeep(false),
+		scan.NewNumRule(scan.IsDigit09).
+			WithDecSep(scan.Rune('.')).
+			WithExp(scan.Rune('e')).
+			WithLeadingZeroAllowed(true),
+	)
+	compare(t, "", "0123 0 00 0.5 01.5e3 007", rs, want)
+
+	toks := scan.NewRunner(scan.NewScannerFromString("", "0123"), rs).All()
+	if len(toks) != 1 || toks[0].Val != "0123" {
+		t.Errorf("\n have: %v \n want: 0123", toks)
+	}
+}
+
+func TestModes(t *testing.T) {
+	s, err := Parse([]byte(`{
+		"rules": [
+			{"rule": "mode", "match": {"rule": "literal", "values": ["\""]}, "push": "str"},
+			{"rule": "ident", "head": "IsLetter", "tail": "IsLetter"}
+		],
+		"modes": {
+			"str": [
+				{"rule": "mode", "match": {"rule": "literal", "values": ["\""]}, "pop": true},
+				{"rule": "while", "class": {"not": {"runes": "\""}}, "type": "text"}
+			]
+		}
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, err := s.RuleSet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []scan.Test{
+		scan.NewTest(`a"b c"d`, "a", 1, 1, scan.IdentType).
+			And(`"`, 1, 2, `"`).
+			And("b c", 1, 3, "text").
+			And(`"`, 1, 6, `"`).
+			And("d", 1, 7, scan.IdentType),
+	}
+	scan.RunTests(t, rs, tests)
+}
+
+func TestClass(t *testing.T) {
+	s, err := Parse([]byte(`{
+		"rules": [
+			{"rule": "while", "class": {"or": ["IsDigit09", {"range": "ac"}]}, "type": "a"},
+			{"rule": "class", "class": {"not": "IsSpace"}, "type": "b"}
+		]
+	}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	rs, err := s.RuleSet()
+	if err != nil {
+		t.Fatal(err)
+	}
+	tests := []scan.Test{
+		scan.NewTest("1abd", "1ab", 1, 1, "a").
+			And("d", 1, 4, "b"),
+	}
+	scan.RunTests(t, rs, tests)
+}
+
+func TestInvalid(t *testing.T) {
+	tests := []struct {
+		src string
+		err string
+	}{
+		{`{"rules": [{"rule": "while", "clas": "IsSpace"}]}`,
+			`invalid spec: json: unknown field "clas"`},
+		{`{"rules": [{"rule": "while", "class": "IsFoo"}]}`,
+			`invalid spec: rules[0]: class: unknown class: "IsFoo"`},
+		{`{"rules": [{"rule": "foo"}]}`,
+			`invalid spec: rules[0]: unknown rule: "foo"`},
+		{`{"rules": [{"rule": "class"}]}`,
+			`invalid spec: rules[0]: class is required`},
+		{`{"rules": [{"rule": "str", "begin": "''", "end": "'"}]}`,
+			`invalid spec: rules[0]: begin must be a single rune: "''"`},
+		{`{"rules": [{"rule": "while", "class": {"range": "a"}}]}`,
+			`invalid spec: rules[0]: class: range must have two runes: "a"`},
+		{`{"modes": {"m": [{}]}}`,
+			`invalid spec: modes.m[0]: rule is required`},
+		{`{"rules": [{"rule": "num", "digits": "IsDigit09", "suffix": [{"rule": "foo"}]}]}`,
+			`invalid spec: rules[0]: suffix[0]: unknown rule: "foo"`},
+	}
+	for _, test := range tests {
+		t.Run(test.src, func(t *testing.T) {
+			s, err := Parse([]byte(test.src))
+			if err == nil {
+				_, err = s.RuleSet()
+			}
+			if !errors.Is(err, ErrInvalidSpec) {
+				t.Fatalf("expected invalid spec error: %v", err)
+			}
+			if err.Error() != test.err {
+				t.Errorf("\n have: %v \n want: %v", err, test.err)
+			}
+		})
+	}
+}
+
+func TestInvalidRule(t *testing.T) {
+	s, err := Parse([]byte(`{"rules": [{"rule": "regex", "pattern": "a(", "type": "a"}]}`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, err := s.RuleSet(); !errors.Is(err, scan.ErrInvalidRule) {
+		t.Errorf("\n have: %v \n want: %v", err, scan.ErrInvalidRule)
+	}
+}
@@ -0,0 +1,21 @@
+package scan
+
+// State returns the value stored with SetState for key or nil if there is
+// no value. Use State to keep information between tokens in rules and in
+// pre and post token functions instead of capturing it in a closure. This
+// allows one RuleSet to be used for many scans, including at the same time.
+//
+// The state is cleared when the scanner is initialized. It is not restored
+// by Reset.
+func (s *Scanner) State(key any) any {
+	return s.state[key]
+}
+
+// SetState stores val for key in the scanner state. Like context keys, key
+// should be of an unexported type to avoid collisions.
+func (s *Scanner) SetState(key any, val any) {
+	if s.state == nil {
+		s.state = make(map[any]any)
+	}
+	s.state[key] = val
+}
@@ -1,6 +1,7 @@
 package scan
 
 import (
+	"os"
 	"strings"
 	"testing"
 )
@@ -57,7 +58,8 @@ func RunTests(t *testing.T, rules RuleSet, tests []Test) {
 					break
 				}
 				testTok := test.toks[i]
-				if tok.Val != testTok.Val || tok.Type != testTok.Type || tok.Pos != testTok.Pos {
+				if tok.Val != testTok.Val || tok.Type != testTok.Type ||
+					tok.Pos.Line != testTok.Pos.Line || tok.Pos.Col != testTok.Pos.Col {
 					t.Fatalf("\n have: %v \n want: %v", tok, testTok)
 				}
 				if len(test.errs) > 0 {
@@ -79,3 +81,26 @@ func RunTests(t *testing.T, rules RuleSet, tests []Test) {
 		})
 	}
 }
+
+// RunGrammarTest checks that the file at path has the grammar written by
+// WriteGrammar for rules. When update is true, the file is written first.
+func RunGrammarTest(t *testing.T, path string, title string, rules RuleSet, update bool) {
+	t.Helper()
+	var b strings.Builder
+	if err := WriteGrammar(&b, title, rules); err != nil {
+		t.Fatal(err)
+	}
+	have := b.String()
+	if update {
+		if err := os.WriteFile(path, []byte(have), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	want, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if have != string(want) {
+		t.Errorf("%v is out of date, run go test -update", path)
+	}
+}
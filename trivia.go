@@ -0,0 +1,135 @@
+package scan
+
+import (
+	"strings"
+	"unicode"
+)
+
+// WithTrivia keeps the whitespace and comments that would otherwise be
+// discarded and attaches them to the tokens as trivia. Trivia found after a
+// token up to and including the end of the line is added to the Trailing
+// tokens. The remaining trivia is added to the Leading tokens of the token
+// that follows. Trivia found at the end of the stream is added to the
+// Trailing tokens of the last token, or to the Leading tokens of the end of
+// text if there are no other tokens.
+//
+// The trailing trivia of the runner's Next token is not complete until it
+// becomes the This token. The source can be rebuilt by writing the literal
+// of each leading token, the token, and each trailing token, in that order.
+func WithTrivia(b bool) RunnerOption {
+	return func(r *Runner) {
+		r.trivia = b
+	}
+}
+
+// addTrivia saves the literal of a token that would otherwise be dropped.
+// Tokens without a type are classified as space when the literal is only
+// whitespace and are otherwise considered a comment.
+func (s *Scanner) addTrivia(t Token) {
+	if !s.keepTrivia || t.Lit == "" {
+		return
+	}
+	if t.Type == "" {
+		t.Type = CommentType
+		if strings.TrimFunc(t.Lit, unicode.IsSpace) == "" {
+			t.Type = SpaceType
+		}
+	}
+	s.trivia = append(s.trivia, t)
+}
+
+// takeTrivia returns the trivia saved since the last call and merges
+// adjacent whitespace into a single token.
+func (s *Scanner) takeTrivia() []Token {
+	if len(s.trivia) == 0 {
+		return nil
+	}
+	var toks []Token
+	var b strings.Builder
+	for _, t := range s.trivia {
+		if n := len(toks); n > 0 {
+			last := &toks[n-1]
+			if last.Type == SpaceType && t.Type == SpaceType && last.End == t.Pos {
+				if b.Len() == 0 {
+					b.WriteString(last.Lit)
+				}
+				b.WriteString(t.Lit)
+				last.End = t.End
+				continue
+			}
+			if b.Len() > 0 {
+				last.Lit = b.String()
+				b.Reset()
+			}
+		}
+		toks = append(toks, t)
+	}
+	if b.Len() > 0 {
+		toks[len(toks)-1].Lit = b.String()
+	}
+	s.trivia = s.trivia[:0]
+	return toks
+}
+
+// attachTrivia adds the trivia found before tok to the trailing tokens of
+// prev and the leading tokens of tok.
+func (r *Runner) attachTrivia(prev *Token, tok *Token) {
+	trivia := r.scan.takeTrivia()
+	if len(trivia) == 0 {
+		return
+	}
+	if prev == nil {
+		tok.Leading = trivia
+		return
+	}
+	if tok.IsEndOfText() {
+		prev.Trailing = append(prev.Trailing, trivia...)
+		return
+	}
+	var i int
+	for ; i < len(trivia); i++ {
+		t := trivia[i]
+		nl := strings.IndexByte(t.Lit, '\n')
+		if nl < 0 {
+			continue
+		}
+		if t.Type != SpaceType {
+			// A comment ending with a newline belongs to the line. A
+			// comment with a newline elsewhere starts on this line but
+			// belongs with the next token.
+			if nl == len(t.Lit)-1 {
+				i++
+			}
+			break
+		}
+		if nl < len(t.Lit)-1 {
+			head, tail := splitTrivia(t, nl+1)
+			prev.Trailing = append(prev.Trailing, trivia[:i]...)
+			prev.Trailing = append(prev.Trailing, head)
+			tok.Leading = append([]Token{tail}, trivia[i+1:]...)
+			return
+		}
+		i++
+		break
+	}
+	prev.Trailing = append(prev.Trailing, trivia[:i]...)
+	tok.Leading = trivia[i:]
+	if len(tok.Leading) == 0 {
+		tok.Leading = nil
+	}
+}
+
+// splitTrivia splits whitespace trivia t after the newline found at byte n.
+func splitTrivia(t Token, n int) (Token, Token) {
+	head, tail := t, t
+	head.Lit = t.Lit[:n]
+	tail.Lit = t.Lit[n:]
+	tail.Pos = Pos{
+		Name:   t.Pos.Name,
+		Line:   t.Pos.Line + strings.Count(head.Lit, "\n"),
+		Col:    1,
+		Offset: t.Pos.Offset + n,
+	}
+	head.End = tail.Pos
+	return head, tail
+}
@@ -0,0 +1,103 @@
+package scan
+
+import (
+	"strings"
+	"testing"
+)
+
+func rebuild(toks []Token, eof Token) string {
+	var b strings.Builder
+	Print(&b, append(toks, eof))
+	return b.String()
+}
+
+func TestTriviaRoundTrip(t *testing.T) {
+	rules := NewRuleSet(
+		SkipSpaceRule,
+		NewCommentRule(Literal("/*"), Literal("*/")),
+		NewCommentRule(Literal("#"), Literal("\n")),
+		StandardIdentRule,
+		IntRule.WithDigitSep(Rune(',')),
+		StrDoubleQuoteRule,
+	)
+	tests := []string{
+		"",
+		"  \n\t ",
+		"abc",
+		"  abc  ",
+		"abc 1,234 \"x\\ny\" # comment\n\n  /* block\n comment */ def\n",
+		"a /* c */ b\n\n# one\n# two\nc",
+		"abc\r\n  def  \r\n",
+		"x ! y",
+	}
+	for _, test := range tests {
+		for _, s := range newTestScanners(test) {
+			r := NewRunner(s, rules, WithTrivia(true))
+			toks := r.All()
+			have := rebuild(toks, r.This)
+			if have != test {
+				t.Errorf("\n have: %q \n want: %q", have, test)
+			}
+		}
+	}
+}
+
+func TestTriviaAttach(t *testing.T) {
+	rules := NewRuleSet(
+		SkipSpaceRule,
+		NewCommentRule(Literal("/*"), Literal("*/")),
+		NewCommentRule(Literal("//"), Literal("\n")),
+		StandardIdentRule,
+	)
+	src := "// head\na /* x */ // tail\n\n  b /* multi\n line */ c  \n"
+	r := NewRunner(NewScannerFromString("", src), rules, WithTrivia(true))
+	toks := r.All()
+	if len(toks) != 3 {
+		t.Fatalf("unexpected tokens: %v", toks)
+	}
+
+	lits := func(ts []Token) []string {
+		var ls []string
+		for _, t := range ts {
+			ls = append(ls, t.Type+":"+t.Lit)
+		}
+		return ls
+	}
+	tests := []struct {
+		have []Token
+		want []string
+	}{
+		{toks[0].Leading, []string{"comment:// head\n"}},
+		{toks[0].Trailing, []string{"space: ", "comment:/* x */", "space: ", "comment:// tail\n"}},
+		{toks[1].Leading, []string{"space:\n  "}},
+		{toks[1].Trailing, []string{"space: "}},
+		{toks[2].Leading, []string{"comment:/* multi\n line */", "space: "}},
+		{toks[2].Trailing, []string{"space:  \n"}},
+	}
+	for i, test := range tests {
+		have := strings.Join(lits(test.have), "|")
+		want := strings.Join(test.want, "|")
+		if have != want {
+			t.Errorf("%v:\n have: %q \n want: %q", i, have, want)
+		}
+	}
+
+	lead := toks[1].Leading[0]
+	if lead.Pos.Line != 3 || lead.Pos.Col != 1 || lead.Pos.Offset != 26 || lead.End.Offset != 29 {
+		t.Errorf("unexpected position: %v %v", lead.Pos, lead.End)
+	}
+}
+
+func TestTriviaMarkReset(t *testing.T) {
+	rules := NewRuleSet(
+		SkipSpaceRule,
+		StandardIdentRule,
+	).WithLongestMatch(true)
+	src := "  abc  def "
+	for _, s := range newTestScanners(src) {
+		r := NewRunner(s, rules, WithTrivia(true))
+		if have := rebuild(r.All(), r.This); have != src {
+			t.Errorf("\n have: %q \n want: %q", have, src)
+		}
+	}
+}
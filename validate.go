@@ -0,0 +1,103 @@
+package scan
+
+import "errors"
+
+// Validator is implemented by rules that can be configured incorrectly. A
+// rule that is not valid reports the error in an ErrorType token when it
+// is evaluated instead of scanning.
+type Validator interface {
+	Validate() error
+}
+
+// Validate checks the configuration of rule if it implements Validator.
+func Validate(rule Rule) error {
+	if v, ok := rule.(Validator); ok {
+		return v.Validate()
+	}
+	return nil
+}
+
+// NewValidRuleSet is like NewRuleSet but returns an error if any of the
+// rules are not valid.
+func NewValidRuleSet(rules ...Rule) (RuleSet, error) {
+	r := NewRuleSet(rules...)
+	return r, r.Validate()
+}
+
+// Validate checks the configuration of all rules in the set and in all of
+// its modes.
+func (r RuleSet) Validate() error {
+	var errs []error
+	for _, rule := range r.rules {
+		if err := Validate(rule); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	for _, mode := range r.modes {
+		if err := mode.Validate(); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (r AltRule) Validate() error {
+	return validateAll(r.rules)
+}
+
+func (r LabelRule) Validate() error {
+	return Validate(r.rule)
+}
+
+func (r ManyRule) Validate() error {
+	return Validate(r.rule)
+}
+
+func (r ModeRule) Validate() error {
+	return Validate(r.rule)
+}
+
+func (r NumRule) Validate() error {
+	errs := []error{Validate(r.prefixRule)}
+	for _, rule := range r.suffixRules {
+		errs = append(errs, Validate(rule))
+	}
+	return errors.Join(errs...)
+}
+
+func (r OptRule) Validate() error {
+	return Validate(r.rule)
+}
+
+func (r PredRule) Validate() error {
+	return Validate(r.rule)
+}
+
+func (r SeqRule) Validate() error {
+	return validateAll(r.rules)
+}
+
+func (r TypedRule) Validate() error {
+	return Validate(r.rule)
+}
+
+func validateAll(rules []Rule) error {
+	var errs []error
+	for _, rule := range rules {
+		errs = append(errs, Validate(rule))
+	}
+	return errors.Join(errs...)
+}
+
+// InvalidRule reports that a rule is not valid. The current rune is kept so
+// that the scanner continues to advance and the token type is set to
+// ErrorType.
+func (s *Scanner) InvalidRule(err error) {
+	s.Type = ErrorType
+	s.Errs = append(s.Errs, Error{
+		Pos:   s.Pos,
+		Cause: err,
+		Code:  "rule.invalid",
+	})
+	s.Keep()
+}